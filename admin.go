@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	roleUser      = "user"
+	roleModerator = "moderator"
+	roleAdmin     = "admin"
+)
+
+// requireRole authenticates the caller and checks that they hold one of
+// roles. It writes the error response itself when ok is false.
+func (cfg *apiConfig) requireRole(w http.ResponseWriter, req *http.Request, roles ...string) (user database.User, ok bool) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return database.User{}, false
+	}
+
+	user, err = cfg.db.GetUserByID(req.Context(), userID)
+	if err != nil || !slices.Contains(roles, user.Role) {
+		writeJSON(w, http.StatusForbidden, errorJSON{
+			Error: "Endpoint forbidden!",
+		})
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// requireAdmin lets only admins through.
+func (cfg *apiConfig) requireAdmin(w http.ResponseWriter, req *http.Request) (database.User, bool) {
+	return cfg.requireRole(w, req, roleAdmin)
+}
+
+// requireStaff lets moderators and admins through.
+func (cfg *apiConfig) requireStaff(w http.ResponseWriter, req *http.Request) (database.User, bool) {
+	return cfg.requireRole(w, req, roleModerator, roleAdmin)
+}
+
+// promoteAdmins makes admins of the users listed by ID in ADMIN_USER_IDS,
+// so a fresh deployment always has someone who can grant the other roles.
+// Users are named by ID rather than email, as emails are not verified and
+// anyone could sign up or switch to a listed address.
+func (cfg *apiConfig) promoteAdmins(ctx context.Context) {
+	if len(cfg.adminIDs) == 0 {
+		return
+	}
+
+	promoted, err := cfg.db.PromoteUsersByID(ctx, cfg.adminIDs)
+	if err != nil {
+		log.Printf("Error promoting admins: %s", err)
+	} else if promoted > 0 {
+		log.Printf("Promoted %d users to admin", promoted)
+	}
+}
+
+func (cfg *apiConfig) handleRestoreChirp(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireStaff(w, req); !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "chirpID must be a valid UUID",
+		})
+		return
+	}
+
+	chirp, err := cfg.restoreChirp(req.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "No deleted chirp with this ID",
+		})
+		return
+	}
+	if isUniqueViolation(err, "chirps_user_id_rechirp_idx") {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: "The user has rechirped this chirp again since",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error restoring chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newChirpJSON(chirp))
+}
+
+// restoreChirp undoes a soft delete and relinks the chirp's hashtags and
+// mentions in one transaction, and announces it to chirp streams.
+func (cfg *apiConfig) restoreChirp(ctx context.Context, chirpID uuid.UUID) (database.Chirp, error) {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.RestoreChirp(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := linkChirpBody(ctx, qtx, chirp); err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := publishChirpEvent(ctx, qtx, chirpEventCreated, chirp); err != nil {
+		return database.Chirp{}, err
+	}
+
+	return chirp, tx.Commit()
+}
+
+// handlePurgeChirp removes a chirp for good, whether or not it was
+// deleted first. Replies to it lose their in_reply_to link, and drafts
+// replying to or quoting it are deleted.
+func (cfg *apiConfig) handlePurgeChirp(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireStaff(w, req); !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "chirpID must be a valid UUID",
+		})
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpByID(req.Context(), chirpID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+
+	err = cfg.db.DeleteChirpByID(req.Context(), chirpID)
+	if err != nil {
+		log.Printf("Error purging chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	if !chirp.DeletedAt.Valid {
+		// Streams already heard about chirps that were deleted first.
+		if err := publishChirpEvent(req.Context(), cfg.db, chirpEventDeleted, chirp); err != nil {
+			log.Printf("Error publishing chirp event: %s", err)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/flames31/Chirpy/internal/auth"
+	"github.com/google/uuid"
+)
+
+var errAccountBanned = errors.New("account is banned")
+
+// authenticatedUserID returns the ID of the user whose JWT is sent in the
+// request's Authorization header.
+func (cfg *apiConfig) authenticatedUserID(req *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return cfg.validateToken(req.Context(), token)
+}
+
+// validateToken returns the ID of the user a JWT was issued to. Tokens of
+// banned users are refused, so a ban takes effect on every endpoint at
+// once rather than when the user's last token expires.
+func (cfg *apiConfig) validateToken(ctx context.Context, token string) (uuid.UUID, error) {
+	userID, err := auth.ValidateJWT(token, cfg.jwtToken)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if user.BannedAt.Valid {
+		return uuid.Nil, errAccountBanned
+	}
+	return user.ID, nil
+}
+
+// viewerID returns the authenticated user for endpoints that also serve
+// anonymous readers, or uuid.Nil when the request carries no valid JWT.
+func (cfg *apiConfig) viewerID(req *http.Request) uuid.UUID {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		return uuid.Nil
+	}
+	return userID
+}
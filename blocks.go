@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type relationJSON struct {
+	userSummaryJSON
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// blockedEitherWay reports whether a and b have blocked each other in
+// either direction. It fails closed: if the lookup errors, the users are
+// treated as blocked.
+func (cfg *apiConfig) blockedEitherWay(ctx context.Context, a, b uuid.UUID) bool {
+	blocked, err := cfg.db.IsBlockedEitherWay(ctx, database.IsBlockedEitherWayParams{
+		UserA: a,
+		UserB: b,
+	})
+	if err != nil {
+		log.Printf("Error checking blocks: %s", err)
+		return true
+	}
+	return blocked
+}
+
+// handleBlockUser blocks a user and removes any follows between the two
+// users, so neither keeps seeing the other in their timeline.
+func (cfg *apiConfig) handleBlockUser(w http.ResponseWriter, req *http.Request) {
+	userID, targetID, ok := cfg.decodeRelationTarget(w, req)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.sqlDB.BeginTx(req.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.CreateBlock(req.Context(), database.CreateBlockParams{
+		BlockerID: userID,
+		BlockedID: targetID,
+	})
+	if err == nil {
+		err = qtx.DeleteFollow(req.Context(), database.DeleteFollowParams{
+			FollowerID: userID,
+			FolloweeID: targetID,
+		})
+	}
+	if err == nil {
+		err = qtx.DeleteFollow(req.Context(), database.DeleteFollowParams{
+			FollowerID: targetID,
+			FolloweeID: userID,
+		})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error blocking user: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleUnblockUser(w http.ResponseWriter, req *http.Request) {
+	userID, targetID, ok := cfg.parseRelationTarget(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.DeleteBlock(req.Context(), database.DeleteBlockParams{
+		BlockerID: userID,
+		BlockedID: targetID,
+	})
+	if err != nil {
+		log.Printf("Error unblocking user: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleGetBlocks(w http.ResponseWriter, req *http.Request) {
+	cfg.writeRelationList(w, req, func(ctx context.Context, userID uuid.UUID, cursor pagination.Cursor, limit int) ([]relationJSON, error) {
+		rows, err := cfg.db.ListBlocks(ctx, database.ListBlocksParams{
+			UserID:          userID,
+			BeforeCreatedAt: cursor.CreatedAt,
+			BeforeID:        cursor.ID,
+			MaxRows:         int32(limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		blocks := []relationJSON{}
+		for _, row := range rows {
+			blocks = append(blocks, relationJSON{
+				userSummaryJSON: userSummaryJSON{ID: row.ID, Handle: nullStringPtr(row.Handle)},
+				CreatedAt:       row.CreatedAt,
+			})
+		}
+		return blocks, nil
+	})
+}
+
+func (cfg *apiConfig) handleMuteUser(w http.ResponseWriter, req *http.Request) {
+	userID, targetID, ok := cfg.decodeRelationTarget(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.CreateMute(req.Context(), database.CreateMuteParams{
+		MuterID: userID,
+		MutedID: targetID,
+	})
+	if err != nil {
+		log.Printf("Error muting user: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleUnmuteUser(w http.ResponseWriter, req *http.Request) {
+	userID, targetID, ok := cfg.parseRelationTarget(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.DeleteMute(req.Context(), database.DeleteMuteParams{
+		MuterID: userID,
+		MutedID: targetID,
+	})
+	if err != nil {
+		log.Printf("Error unmuting user: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleGetMutes(w http.ResponseWriter, req *http.Request) {
+	cfg.writeRelationList(w, req, func(ctx context.Context, userID uuid.UUID, cursor pagination.Cursor, limit int) ([]relationJSON, error) {
+		rows, err := cfg.db.ListMutes(ctx, database.ListMutesParams{
+			UserID:          userID,
+			BeforeCreatedAt: cursor.CreatedAt,
+			BeforeID:        cursor.ID,
+			MaxRows:         int32(limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		mutes := []relationJSON{}
+		for _, row := range rows {
+			mutes = append(mutes, relationJSON{
+				userSummaryJSON: userSummaryJSON{ID: row.ID, Handle: nullStringPtr(row.Handle)},
+				CreatedAt:       row.CreatedAt,
+			})
+		}
+		return mutes, nil
+	})
+}
+
+// decodeRelationTarget authenticates the caller and reads the user to
+// block or mute from the JSON body. It writes the error response itself
+// when ok is false.
+func (cfg *apiConfig) decodeRelationTarget(w http.ResponseWriter, req *http.Request) (userID, targetID uuid.UUID, ok bool) {
+	type incoming struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil || incomingJSON.UserID == uuid.Nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "user_id must be a valid UUID",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if incomingJSON.UserID == userID {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "You cannot block or mute yourself",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if _, err := cfg.db.GetUserByID(req.Context(), incomingJSON.UserID); err != nil {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "User not found",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, incomingJSON.UserID, true
+}
+
+// parseRelationTarget authenticates the caller and reads the user to
+// unblock or unmute from the path. It writes the error response itself
+// when ok is false.
+func (cfg *apiConfig) parseRelationTarget(w http.ResponseWriter, req *http.Request) (userID, targetID uuid.UUID, ok bool) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	targetID, err = uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "userID must be a valid UUID",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, targetID, true
+}
+
+// writeRelationList pages through the caller's blocks or mutes, newest
+// first.
+func (cfg *apiConfig) writeRelationList(w http.ResponseWriter, req *http.Request, list func(context.Context, uuid.UUID, pagination.Cursor, int) ([]relationJSON, error)) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	relations, err := list(req.Context(), userID, cursor, limit+1)
+	if err != nil {
+		log.Printf("Error fetching relations: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(relations) > limit {
+		relations = relations[:limit]
+		last := relations[limit-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	writeJSON(w, http.StatusOK, relations)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+// handleBookmarkChirp saves a chirp for the caller, optionally in one of
+// their collections. Bookmarking an already bookmarked chirp moves it to
+// the given collection, or out of any collection when none is given.
+func (cfg *apiConfig) handleBookmarkChirp(w http.ResponseWriter, req *http.Request) {
+	type incoming struct {
+		CollectionID *uuid.UUID `json:"collection_id"`
+	}
+
+	userID, chirp, ok := cfg.authorizeChirpAction(w, req)
+	if !ok {
+		return
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+
+	collectionID := uuid.NullUUID{}
+	if incomingJSON.CollectionID != nil {
+		if _, ok := cfg.ownCollection(w, req, userID, *incomingJSON.CollectionID); !ok {
+			return
+		}
+		collectionID = uuid.NullUUID{UUID: *incomingJSON.CollectionID, Valid: true}
+	}
+
+	err := cfg.db.UpsertBookmark(req.Context(), database.UpsertBookmarkParams{
+		UserID:       userID,
+		ChirpID:      chirp.ID,
+		CollectionID: collectionID,
+	})
+	if err != nil {
+		log.Printf("Error bookmarking chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleUnbookmarkChirp(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "chirpID must be a valid UUID",
+		})
+		return
+	}
+
+	// Unlike bookmarking, this works on deleted chirps too, so users can
+	// always clean up.
+	err = cfg.db.DeleteBookmark(req.Context(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		log.Printf("Error removing bookmark: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetBookmarks lists the caller's bookmarked chirps, most recently
+// bookmarked first, optionally only those in one collection.
+func (cfg *apiConfig) handleGetBookmarks(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	collectionID := uuid.NullUUID{}
+	if collectionIDStr := req.URL.Query().Get("collection_id"); collectionIDStr != "" {
+		id, err := uuid.Parse(collectionIDStr)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "collection_id must be a valid UUID",
+			})
+			return
+		}
+		if _, ok := cfg.ownCollection(w, req, userID, id); !ok {
+			return
+		}
+		collectionID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	rows, err := cfg.db.ListBookmarks(req.Context(), database.ListBookmarksParams{
+		UserID:          userID,
+		CollectionID:    collectionID,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		MaxRows:         int32(limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error fetching bookmarks: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.BookmarkedAt, ID: last.Chirp.ID})
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	cfg.writeChirps(w, req, http.StatusOK, chirps)
+}
+
+// ownCollection fetches one of userID's collections. Other users'
+// collections are reported as not found. It writes the error response
+// itself when ok is false.
+func (cfg *apiConfig) ownCollection(w http.ResponseWriter, req *http.Request, userID, collectionID uuid.UUID) (database.Collection, bool) {
+	collection, err := cfg.db.GetCollectionByID(req.Context(), database.GetCollectionByIDParams{
+		ID:     collectionID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Collection not found",
+		})
+		return database.Collection{}, false
+	}
+	if err != nil {
+		log.Printf("Error fetching collection: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return database.Collection{}, false
+	}
+	return collection, true
+}
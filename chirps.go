@@ -1,29 +1,313 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/flames31/Chirpy/internal/auth"
+	"github.com/flames31/Chirpy/internal/chirptext"
 	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/moderation"
+	"github.com/flames31/Chirpy/internal/pagination"
 	"github.com/google/uuid"
 )
 
+const (
+	chirpKindChirp   = "chirp"
+	chirpKindRechirp = "rechirp"
+	chirpKindQuote   = "quote"
+)
+
+// chirpSummaryJSON is the short form of a chirp embedded in rechirps and
+// quotes. A referenced chirp that has since been deleted keeps only its ID.
+type chirpSummaryJSON struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Body      string     `json:"body,omitempty"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	Deleted   bool       `json:"deleted,omitempty"`
+	Hidden    bool       `json:"hidden,omitempty"`
+}
+
+// chirpJSON is the public form of a chirp. ReferenceChirpID is null for a
+// rechirp or quote whose original was removed for good.
 type chirpJSON struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID               uuid.UUID         `json:"id"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	Body             string            `json:"body"`
+	UserID           uuid.UUID         `json:"user_id"`
+	InReplyTo        *uuid.UUID        `json:"in_reply_to"`
+	Kind             string            `json:"kind"`
+	ReferenceChirpID *uuid.UUID        `json:"reference_chirp_id"`
+	Reference        *chirpSummaryJSON `json:"reference"`
+	ReplyCount       int64             `json:"reply_count"`
+	LikeCount        int64             `json:"like_count"`
+	LikedByMe        *bool             `json:"liked_by_me,omitempty"`
+	BookmarkedByMe   *bool             `json:"bookmarked_by_me,omitempty"`
+	Pinned           bool              `json:"pinned,omitempty"`
+	Media            []mediaJSON       `json:"media"`
+	Poll             *pollJSON         `json:"poll"`
+	Edited           bool              `json:"edited"`
+	Deleted          bool              `json:"deleted,omitempty"`
+	DeletedAt        *time.Time        `json:"deleted_at,omitempty"`
+}
+
+// newChirpJSON converts a single row without any of the counts that
+// renderChirps loads. Deleted chirps become tombstones with no body.
+func newChirpJSON(chirp database.Chirp) chirpJSON {
+	c := chirpJSON{
+		ID:        chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body:      chirp.Body,
+		UserID:    chirp.UserID,
+		Kind:      chirp.Kind,
+		Media:     []mediaJSON{},
+		Edited:    chirp.EditedAt.Valid,
+	}
+	if chirp.InReplyTo.Valid {
+		c.InReplyTo = &chirp.InReplyTo.UUID
+	}
+	if chirp.ReferenceChirpID.Valid {
+		c.ReferenceChirpID = &chirp.ReferenceChirpID.UUID
+	}
+	if chirp.DeletedAt.Valid {
+		c.Body = ""
+		c.Deleted = true
+		c.DeletedAt = &chirp.DeletedAt.Time
+	}
+	return c
+}
+
+func newChirpSummaryJSON(chirp database.Chirp) *chirpSummaryJSON {
+	if chirp.DeletedAt.Valid {
+		return &chirpSummaryJSON{ID: chirp.ID, Deleted: true}
+	}
+	return &chirpSummaryJSON{
+		ID:        chirp.ID,
+		CreatedAt: &chirp.CreatedAt,
+		Body:      chirp.Body,
+		UserID:    &chirp.UserID,
+	}
+}
+
+// renderChirps converts chirps to their JSON form, loading the counts
+// for the whole slice in one batch instead of one query per chirp. A
+// non-nil viewerID also fills in what that user has done to each chirp.
+//
+// Every chirp response goes through here, so this is also where blocks
+// and mutes are enforced: chirps by users hidden from the viewer are
+// dropped even if the query that loaded them forgot to filter them out.
+func (cfg *apiConfig) renderChirps(ctx context.Context, viewerID uuid.UUID, chirps []database.Chirp) ([]chirpJSON, error) {
+	chirpsJSON := []chirpJSON{}
+
+	hidden := map[uuid.UUID]bool{}
+	if viewerID != uuid.Nil {
+		hiddenIDs, err := cfg.db.ListHiddenUserIDs(ctx, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range hiddenIDs {
+			hidden[id] = true
+		}
+	}
+
+	visible := make([]database.Chirp, 0, len(chirps))
+	for _, chirp := range chirps {
+		if !hidden[chirp.UserID] {
+			visible = append(visible, chirp)
+		}
+	}
+	chirps = visible
+	if len(chirps) == 0 {
+		return chirpsJSON, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(chirps))
+	refIDs := []uuid.UUID{}
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+		if chirp.ReferenceChirpID.Valid {
+			refIDs = append(refIDs, chirp.ReferenceChirpID.UUID)
+		}
+	}
+
+	references := map[uuid.UUID]database.Chirp{}
+	if len(refIDs) > 0 {
+		refs, err := cfg.db.GetChirpsByIDs(ctx, refIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			references[ref.ID] = ref
+		}
+	}
+
+	replyCounts := map[uuid.UUID]int64{}
+	rows, err := cfg.db.CountRepliesByChirpIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		replyCounts[row.ChirpID] = row.Replies
+	}
+
+	likeCounts := map[uuid.UUID]int64{}
+	likeRows, err := cfg.db.CountLikesByChirpIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range likeRows {
+		likeCounts[row.ChirpID] = row.Likes
+	}
+
+	attachments := map[uuid.UUID][]mediaJSON{}
+	mediaRows, err := cfg.db.ListChirpMediaByChirpIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mediaIDs := make([]uuid.UUID, 0, len(mediaRows))
+	for _, row := range mediaRows {
+		mediaIDs = append(mediaIDs, row.Media.ID)
+	}
+	variants, err := cfg.loadMediaVariants(ctx, mediaIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range mediaRows {
+		attachments[row.ChirpID] = append(attachments[row.ChirpID], cfg.newMediaJSON(row.Media, variants[row.Media.ID]))
+	}
+
+	polls, err := cfg.loadPolls(ctx, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	liked := map[uuid.UUID]bool{}
+	if viewerID != uuid.Nil {
+		likedIDs, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+			UserID:   viewerID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range likedIDs {
+			liked[id] = true
+		}
+	}
+
+	bookmarked := map[uuid.UUID]bool{}
+	if viewerID != uuid.Nil {
+		bookmarkedIDs, err := cfg.db.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+			UserID:   viewerID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range bookmarkedIDs {
+			bookmarked[id] = true
+		}
+	}
+
+	for _, chirp := range chirps {
+		c := newChirpJSON(chirp)
+		c.ReplyCount = replyCounts[chirp.ID]
+		c.LikeCount = likeCounts[chirp.ID]
+		if !chirp.DeletedAt.Valid {
+			if attached, ok := attachments[chirp.ID]; ok {
+				c.Media = attached
+			}
+			c.Poll = polls[chirp.ID]
+		}
+		if ref, ok := references[chirp.ReferenceChirpID.UUID]; ok {
+			if hidden[ref.UserID] {
+				c.Reference = &chirpSummaryJSON{ID: ref.ID, Hidden: true}
+			} else {
+				c.Reference = newChirpSummaryJSON(ref)
+			}
+		}
+		if viewerID != uuid.Nil {
+			likedByMe := liked[chirp.ID]
+			c.LikedByMe = &likedByMe
+			bookmarkedByMe := bookmarked[chirp.ID]
+			c.BookmarkedByMe = &bookmarkedByMe
+		}
+		chirpsJSON = append(chirpsJSON, c)
+	}
+
+	return chirpsJSON, nil
+}
+
+// writeChirps renders chirps and writes them as a JSON array.
+func (cfg *apiConfig) writeChirps(w http.ResponseWriter, req *http.Request, status int, chirps []database.Chirp) {
+	chirpsJSON, err := cfg.renderChirps(req.Context(), cfg.viewerID(req), chirps)
+	if err != nil {
+		log.Printf("Error rendering chirps: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, status, chirpsJSON)
+}
+
+// writeChirp renders a single chirp and writes it as a JSON object. A
+// chirp hidden from the caller is reported as not found.
+func (cfg *apiConfig) writeChirp(w http.ResponseWriter, req *http.Request, status int, chirp database.Chirp) {
+	chirpsJSON, err := cfg.renderChirps(req.Context(), cfg.viewerID(req), []database.Chirp{chirp})
+	if err != nil {
+		log.Printf("Error rendering chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	if len(chirpsJSON) == 0 {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+
+	writeJSON(w, status, chirpsJSON[0])
+}
+
+// chirpInput is the client's description of a new chirp, shared by
+// POST /api/chirps and the drafts endpoints.
+type chirpInput struct {
+	Body             string      `json:"body"`
+	InReplyTo        *uuid.UUID  `json:"in_reply_to"`
+	Kind             string      `json:"kind"`
+	ReferenceChirpID *uuid.UUID  `json:"reference_chirp_id"`
+	MediaIDs         []uuid.UUID `json:"media_ids"`
+	Poll             *pollInput  `json:"poll"`
+}
+
+// newChirp is a validated chirp ready to be inserted, together with the
+// uploads and poll to attach to it and the moderation rules it matched.
+type newChirp struct {
+	database.CreateChirpParams
+	MediaIDs []uuid.UUID
+	Poll     *pollInput
+	Matched  []moderation.Rule
 }
 
 func (cfg *apiConfig) handleCreateChirp(w http.ResponseWriter, req *http.Request) {
 	type incoming struct {
-		Body   string    `json:"body"`
-		UserID uuid.UUID `json:"user_id"`
+		chirpInput
+		UserID    uuid.UUID  `json:"user_id"`
+		PublishAt *time.Time `json:"publish_at"`
 	}
 
 	incomingJSON := incoming{}
@@ -44,7 +328,7 @@ func (cfg *apiConfig) handleCreateChirp(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.jwtToken)
+	userID, err := cfg.validateToken(req.Context(), token)
 	if err != nil {
 		writeJSON(w, http.StatusUnauthorized, errorJSON{
 			Error: "User not authorized",
@@ -52,18 +336,52 @@ func (cfg *apiConfig) handleCreateChirp(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	cleanBody, ok := validateChirp(incomingJSON.Body)
-	if !ok {
-		writeJSON(w, http.StatusBadRequest, errorJSON{
-			Error: "Chirp is too long",
+	chirp, status, msg := cfg.prepareChirp(req.Context(), userID, incomingJSON.chirpInput)
+	if msg != "" {
+		writeJSON(w, status, errorJSON{
+			Error: msg,
+		})
+		return
+	}
+
+	// A chirp scheduled for later waits as a draft until the publisher
+	// picks it up; one due now or in the past is simply posted.
+	if incomingJSON.PublishAt != nil && incomingJSON.PublishAt.After(time.Now()) {
+		if chirp.Poll != nil {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "Polls cannot be scheduled",
+			})
+			return
+		}
+
+		draft, err := cfg.db.CreateDraft(req.Context(), database.CreateDraftParams{
+			UserID:           chirp.UserID,
+			Body:             chirp.Body,
+			InReplyTo:        chirp.InReplyTo,
+			Kind:             chirp.Kind,
+			ReferenceChirpID: chirp.ReferenceChirpID,
+			PublishAt:        sql.NullTime{Time: incomingJSON.PublishAt.UTC(), Valid: true},
+			MediaIds:         chirp.MediaIDs,
 		})
+		if err != nil {
+			log.Printf("Error creating draft: %s", err)
+			writeJSON(w, http.StatusInternalServerError, errorJSON{
+				Error: "Something went wrong",
+			})
+			return
+		}
+
+		writeJSON(w, http.StatusAccepted, newDraftJSON(draft))
 		return
 	}
 
-	chirp, err := cfg.db.CreateChirp(req.Context(), database.CreateChirpParams{
-		Body:   cleanBody,
-		UserID: userID,
-	})
+	created, err := cfg.createChirp(req.Context(), chirp)
+	if isUniqueViolation(err, "chirps_user_id_rechirp_idx") {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: "Chirp is already rechirped",
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error creating chirp: %s", err)
 		writeJSON(w, http.StatusInternalServerError, errorJSON{
@@ -72,17 +390,139 @@ func (cfg *apiConfig) handleCreateChirp(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, chirpJSON{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	cfg.notifyChirp(req.Context(), created)
+	cfg.writeChirp(w, req, http.StatusCreated, created)
+}
+
+// prepareChirp validates a new chirp by userID and resolves what it
+// replies to and references. When the input is rejected it returns the
+// status and message to send back instead.
+func (cfg *apiConfig) prepareChirp(ctx context.Context, userID uuid.UUID, input chirpInput) (newChirp, int, string) {
+	kind := input.Kind
+	if kind == "" {
+		kind = chirpKindChirp
+	}
+	if msg := validateChirpKind(kind, input.Body, input.InReplyTo, input.ReferenceChirpID); msg != "" {
+		return newChirp{}, http.StatusBadRequest, msg
+	}
+
+	if kind == chirpKindRechirp && len(input.MediaIDs) > 0 {
+		return newChirp{}, http.StatusBadRequest, "A rechirp cannot have media"
+	}
+
+	var poll *pollInput
+	var matched []moderation.Rule
+	if input.Poll != nil {
+		if kind == chirpKindRechirp {
+			return newChirp{}, http.StatusBadRequest, "A rechirp cannot have a poll"
+		}
+		validated, pollMatched, msg := cfg.validatePoll(*input.Poll)
+		if msg != "" {
+			return newChirp{}, http.StatusBadRequest, msg
+		}
+		poll = &validated
+		matched = pollMatched
+	}
+
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if err != nil {
+		log.Printf("Error fetching user: %s", err)
+		return newChirp{}, http.StatusInternalServerError, "Something went wrong"
+	}
+	// Requests from banned users are already refused at authentication;
+	// this stops their scheduled drafts.
+	if user.BannedAt.Valid {
+		return newChirp{}, http.StatusForbidden, "This account has been banned"
+	}
+
+	checked, msg := cfg.validateChirp(input.Body, cfg.chirpLengthLimit(user))
+	if msg != "" {
+		return newChirp{}, http.StatusBadRequest, msg
+	}
+
+	referenceChirpID := uuid.NullUUID{}
+	if input.ReferenceChirpID != nil {
+		ref, err := cfg.db.GetChirpByID(ctx, *input.ReferenceChirpID)
+		if err == nil && ref.Kind == chirpKindRechirp && ref.ReferenceChirpID.Valid {
+			// Re-sharing a rechirp shares the chirp it points at.
+			ref, err = cfg.db.GetChirpByID(ctx, ref.ReferenceChirpID.UUID)
+		}
+		if err != nil || ref.DeletedAt.Valid || ref.Kind == chirpKindRechirp {
+			return newChirp{}, http.StatusBadRequest, "reference_chirp_id does not reference an existing chirp"
+		}
+		if cfg.blockedEitherWay(ctx, userID, ref.UserID) {
+			return newChirp{}, http.StatusForbidden, "You cannot share chirps from this user"
+		}
+		referenceChirpID = uuid.NullUUID{UUID: ref.ID, Valid: true}
+	}
+
+	inReplyTo := uuid.NullUUID{}
+	if input.InReplyTo != nil {
+		parent, err := cfg.db.GetChirpByID(ctx, *input.InReplyTo)
+		if err != nil || parent.DeletedAt.Valid {
+			return newChirp{}, http.StatusBadRequest, "in_reply_to does not reference an existing chirp"
+		}
+		if cfg.blockedEitherWay(ctx, userID, parent.UserID) {
+			return newChirp{}, http.StatusForbidden, "You cannot reply to this user"
+		}
+		inReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	mediaIDs, status, msg := cfg.resolveMedia(ctx, userID, input.MediaIDs)
+	if msg != "" {
+		return newChirp{}, status, msg
+	}
+
+	return newChirp{
+		CreateChirpParams: database.CreateChirpParams{
+			Body:             checked.Text,
+			UserID:           userID,
+			InReplyTo:        inReplyTo,
+			Kind:             kind,
+			ReferenceChirpID: referenceChirpID,
+		},
+		MediaIDs: mediaIDs,
+		Poll:     poll,
+		Matched:  append(checked.Matched, matched...),
+	}, 0, ""
 }
 
 func (cfg *apiConfig) handleGetAllChirps(w http.ResponseWriter, req *http.Request) {
-	chirps, err := cfg.db.GetAllChirps(req.Context())
+	query := chirpQuery{ViewerID: cfg.viewerID(req)}
+
+	if authorIDStr := req.URL.Query().Get("author_id"); authorIDStr != "" {
+		authorID, err := uuid.Parse(authorIDStr)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "author_id must be a valid UUID",
+			})
+			return
+		}
+		query.AuthorID = uuid.NullUUID{UUID: authorID, Valid: true}
+	}
+
+	switch req.URL.Query().Get("sort") {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "sort must be either asc or desc",
+		})
+		return
+	}
+
+	cursor, limit, err := parsePage(req, query.Desc)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+	query.Cursor = cursor
+	query.Limit = limit + 1
+
+	chirps, err := cfg.listChirps(req.Context(), query)
 	if err != nil {
 		log.Printf("Error fetching all chirps: %s", err)
 		writeJSON(w, http.StatusInternalServerError, errorJSON{
@@ -90,19 +530,164 @@ func (cfg *apiConfig) handleGetAllChirps(w http.ResponseWriter, req *http.Reques
 		})
 		return
 	}
-	chirpsJSON := []chirpJSON{}
 
-	for _, chirp := range chirps {
-		chirpsJSON = append(chirpsJSON, chirpJSON{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID,
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		last := chirps[limit-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	if query.AuthorID.Valid {
+		cfg.writeAuthorChirps(w, req, query, chirps, req.URL.Query().Get("cursor") == "")
+		return
+	}
+
+	cfg.writeChirps(w, req, http.StatusOK, chirps)
+}
+
+// createChirp stores a chirp and links it to the hashtags and mentioned
+// users in its body in a single transaction.
+func (cfg *apiConfig) createChirp(ctx context.Context, c newChirp) (database.Chirp, error) {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := insertChirp(ctx, qtx, c)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	return chirp, tx.Commit()
+}
+
+// insertChirp creates a chirp along with its hashtags, mentions, media,
+// poll and moderation flag inside the caller's transaction, and announces
+// it to chirp streams once that commits.
+func insertChirp(ctx context.Context, qtx *database.Queries, c newChirp) (database.Chirp, error) {
+	chirp, err := qtx.CreateChirp(ctx, c.CreateChirpParams)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := linkChirpBody(ctx, qtx, chirp); err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := linkChirpMedia(ctx, qtx, chirp.ID, c.MediaIDs); err != nil {
+		return database.Chirp{}, err
+	}
+
+	if c.Poll != nil {
+		if err := createPoll(ctx, qtx, chirp.ID, *c.Poll); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	if err := flagChirp(ctx, qtx, chirp.ID, c.Matched); err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := publishChirpEvent(ctx, qtx, chirpEventCreated, chirp); err != nil {
+		return database.Chirp{}, err
+	}
+
+	return chirp, nil
+}
+
+// linkChirpBody records the hashtags and mentioned users in a chirp's
+// body. Mentions of handles that do not belong to anyone, or whose user
+// has a block with the author in either direction, stay plain text.
+func linkChirpBody(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	for _, tag := range chirptext.Hashtags(chirp.Body) {
+		hashtag, err := qtx.UpsertHashtag(ctx, tag)
+		if err != nil {
+			return err
+		}
+		err = qtx.CreateChirpHashtag(ctx, database.CreateChirpHashtagParams{
+			ChirpID:   chirp.ID,
+			HashtagID: hashtag.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	handles := chirptext.Mentions(chirp.Body)
+	if len(handles) == 0 {
+		return nil
+	}
+
+	mentioned, err := qtx.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+	blockedIDs, err := qtx.ListBlockedEitherWay(ctx, chirp.UserID)
+	if err != nil {
+		return err
+	}
+	for _, user := range mentioned {
+		if slices.Contains(blockedIDs, user.ID) {
+			continue
+		}
+		err = qtx.CreateChirpMention(ctx, database.CreateChirpMentionParams{
+			ChirpID: chirp.ID,
+			UserID:  user.ID,
 		})
+		if err != nil {
+			return err
+		}
 	}
 
-	writeJSON(w, http.StatusOK, chirpsJSON)
+	return nil
+}
+
+// chirpQuery describes one page of chirps. A zero AuthorID lists chirps
+// from every user. Chirps by users that ViewerID blocked, muted or was
+// blocked by are left out.
+type chirpQuery struct {
+	ViewerID uuid.UUID
+	AuthorID uuid.NullUUID
+	Desc     bool
+	Cursor   pagination.Cursor
+	Limit    int
+}
+
+func (cfg *apiConfig) listChirps(ctx context.Context, q chirpQuery) ([]database.Chirp, error) {
+	switch {
+	case q.AuthorID.Valid && q.Desc:
+		return cfg.db.ListChirpsByAuthorDesc(ctx, database.ListChirpsByAuthorDescParams{
+			UserID:          q.AuthorID.UUID,
+			ViewerID:        q.ViewerID,
+			BeforeCreatedAt: q.Cursor.CreatedAt,
+			BeforeID:        q.Cursor.ID,
+			MaxRows:         int32(q.Limit),
+		})
+	case q.AuthorID.Valid:
+		return cfg.db.ListChirpsByAuthor(ctx, database.ListChirpsByAuthorParams{
+			UserID:         q.AuthorID.UUID,
+			ViewerID:       q.ViewerID,
+			AfterCreatedAt: q.Cursor.CreatedAt,
+			AfterID:        q.Cursor.ID,
+			MaxRows:        int32(q.Limit),
+		})
+	case q.Desc:
+		return cfg.db.ListChirpsDesc(ctx, database.ListChirpsDescParams{
+			ViewerID:        q.ViewerID,
+			BeforeCreatedAt: q.Cursor.CreatedAt,
+			BeforeID:        q.Cursor.ID,
+			MaxRows:         int32(q.Limit),
+		})
+	default:
+		return cfg.db.ListChirps(ctx, database.ListChirpsParams{
+			ViewerID:       q.ViewerID,
+			AfterCreatedAt: q.Cursor.CreatedAt,
+			AfterID:        q.Cursor.ID,
+			MaxRows:        int32(q.Limit),
+		})
+	}
 }
 
 func (cfg *apiConfig) handleGetChirp(w http.ResponseWriter, req *http.Request) {
@@ -122,30 +707,32 @@ func (cfg *apiConfig) handleGetChirp(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, chirpJSON{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	if chirp.DeletedAt.Valid {
+		writeJSON(w, http.StatusGone, newChirpJSON(chirp))
+		return
+	}
+
+	cfg.writeChirp(w, req, http.StatusOK, chirp)
 }
 
-func (cfg *apiConfig) handleDeleteChirp(w http.ResponseWriter, req *http.Request) {
+// ownChirp fetches the chirp in the path and checks that the caller
+// wrote it. Deleted chirps are reported as not found. It writes the error
+// response itself when ok is false.
+func (cfg *apiConfig) ownChirp(w http.ResponseWriter, req *http.Request) (chirp database.Chirp, ok bool) {
 	idStr := req.PathValue("chirpID")
 
 	chirpID, err := uuid.Parse(idStr)
 	if err != nil {
 		log.Printf("Error while converting to UUID!")
 		writeJSON(w, http.StatusInternalServerError, errorJSON{Error: "Something went wrong"})
-		return
+		return database.Chirp{}, false
 	}
 
-	chirp, err := cfg.db.GetChirpByID(req.Context(), chirpID)
-	if err != nil {
+	chirp, err = cfg.db.GetChirpByID(req.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
 		log.Printf("Error while retrieveing chirp / Given ChirpID does not exist!")
 		writeJSON(w, http.StatusNotFound, errorJSON{Error: "Something went wrong"})
-		return
+		return database.Chirp{}, false
 	}
 
 	token, err := auth.GetBearerToken(req.Header)
@@ -154,25 +741,37 @@ func (cfg *apiConfig) handleDeleteChirp(w http.ResponseWriter, req *http.Request
 		writeJSON(w, http.StatusUnauthorized, errorJSON{
 			Error: "Incorrect toke / No token provided",
 		})
-		return
+		return database.Chirp{}, false
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.jwtToken)
+	userID, err := cfg.validateToken(req.Context(), token)
 	if err != nil {
 		writeJSON(w, http.StatusUnauthorized, errorJSON{
 			Error: "User not authorized",
 		})
-		return
+		return database.Chirp{}, false
 	}
 
 	if chirp.UserID != userID {
 		writeJSON(w, http.StatusForbidden, errorJSON{
 			Error: "Forbidden",
 		})
+		return database.Chirp{}, false
+	}
+
+	return chirp, true
+}
+
+func (cfg *apiConfig) handleDeleteChirp(w http.ResponseWriter, req *http.Request) {
+	chirp, ok := cfg.ownChirp(w, req)
+	if !ok {
 		return
 	}
 
-	err = cfg.db.DeleteChirpByID(req.Context(), chirpID)
+	// The chirp is kept as a tombstone so replies and moderators still
+	// have its context; runChirpPurger removes it after the retention
+	// period.
+	err := cfg.deleteChirp(req.Context(), chirp)
 	if err != nil {
 		log.Printf("Error deleting chirp: %s", err)
 		writeJSON(w, http.StatusInternalServerError, errorJSON{
@@ -184,23 +783,89 @@ func (cfg *apiConfig) handleDeleteChirp(w http.ResponseWriter, req *http.Request
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func validateChirp(body string) (string, bool) {
-	if len(body) > 140 {
-		return "", false
+// deleteChirp soft-deletes a chirp in its own transaction and announces
+// it to chirp streams.
+func (cfg *apiConfig) deleteChirp(ctx context.Context, chirp database.Chirp) error {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	if err := softDeleteChirp(ctx, qtx, chirp.ID); err != nil {
+		return err
+	}
+
+	if err := publishChirpEvent(ctx, qtx, chirpEventDeleted, chirp); err != nil {
+		return err
 	}
-	return getCleanBody(body), true
+
+	return tx.Commit()
 }
 
-func getCleanBody(str string) string {
-	const asteriks = "****"
-	badWords := map[string]bool{"kerfuffle": true, "sharbert": true, "fornax": true}
+// softDeleteChirp turns a chirp into a tombstone inside the caller's
+// transaction. Its hashtag and mention links are removed with it, so it
+// stops counting towards trending tags; restoreChirp links them again.
+func softDeleteChirp(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID) error {
+	if err := qtx.SoftDeleteChirp(ctx, chirpID); err != nil {
+		return err
+	}
+	if err := qtx.DeleteChirpHashtags(ctx, chirpID); err != nil {
+		return err
+	}
+	return qtx.DeleteChirpMentions(ctx, chirpID)
+}
 
-	strSlice := strings.Split(str, " ")
-	for i, s := range strSlice {
-		if _, ok := badWords[strings.ToLower(s)]; ok {
-			strSlice[i] = asteriks
+// validateChirpKind checks that the payload fits its kind: a rechirp only
+// points at another chirp, a quote adds a comment to one and a plain chirp
+// references nothing. It returns an error message, or "" if valid.
+func validateChirpKind(kind, body string, inReplyTo, referenceChirpID *uuid.UUID) string {
+	switch kind {
+	case chirpKindChirp:
+		if referenceChirpID != nil {
+			return "reference_chirp_id is only allowed for rechirps and quotes"
+		}
+	case chirpKindRechirp:
+		if referenceChirpID == nil {
+			return "A rechirp needs a reference_chirp_id"
+		}
+		if body != "" || inReplyTo != nil {
+			return "A rechirp cannot have a body or be a reply"
+		}
+	case chirpKindQuote:
+		if referenceChirpID == nil {
+			return "A quote needs a reference_chirp_id"
 		}
+		if strings.TrimSpace(body) == "" {
+			return "A quote needs a body"
+		}
+	default:
+		return "kind must be one of chirp, rechirp or quote"
+	}
+	return ""
+}
+
+// validateChirp checks body against a length limit and the moderation
+// rules, masking words where the rules say so. On failure it returns a
+// message saying what was wrong.
+func (cfg *apiConfig) validateChirp(body string, limit int) (moderation.Result, string) {
+	if n := chirptext.Length(body); n > limit {
+		return moderation.Result{}, fmt.Sprintf("Chirp is too long: %d characters used, %d allowed", n, limit)
 	}
 
-	return strings.Join(strSlice, " ")
+	result := cfg.moderate(body)
+	if result.Rejected {
+		return moderation.Result{}, "Chirp contains language that is not allowed"
+	}
+	return result, ""
+}
+
+// chirpLengthLimit is how long a user's chirps may be. Chirpy Red members
+// get a higher limit.
+func (cfg *apiConfig) chirpLengthLimit(user database.User) int {
+	if user.IsChirpyRed {
+		return cfg.redMaxChirpLength
+	}
+	return cfg.maxChirpLength
 }
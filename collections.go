@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/chirptext"
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxCollections          = 100
+	maxCollectionNameLength = 50
+)
+
+type collectionJSON struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	Position  int32     `json:"position"`
+}
+
+func newCollectionJSON(collection database.Collection) collectionJSON {
+	return collectionJSON{
+		ID:        collection.ID,
+		CreatedAt: collection.CreatedAt,
+		UpdatedAt: collection.UpdatedAt,
+		Name:      collection.Name,
+		Position:  collection.Position,
+	}
+}
+
+// decodeCollectionName reads and checks the name of a collection being
+// created or renamed. It writes the error response itself when ok is
+// false.
+func decodeCollectionName(w http.ResponseWriter, req *http.Request) (name string, ok bool) {
+	type incoming struct {
+		Name string `json:"name"`
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return "", false
+	}
+
+	name = strings.TrimSpace(incomingJSON.Name)
+	if name == "" || chirptext.Length(name) > maxCollectionNameLength {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: fmt.Sprintf("name must be between 1 and %d characters", maxCollectionNameLength),
+		})
+		return "", false
+	}
+	return name, true
+}
+
+func (cfg *apiConfig) handleCreateCollection(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	name, ok := decodeCollectionName(w, req)
+	if !ok {
+		return
+	}
+
+	count, err := cfg.db.CountCollectionsByUser(req.Context(), userID)
+	if err != nil {
+		log.Printf("Error counting collections: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	if count >= maxCollections {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: fmt.Sprintf("You can have at most %d collections", maxCollections),
+		})
+		return
+	}
+
+	collection, err := cfg.db.CreateCollection(req.Context(), database.CreateCollectionParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if isUniqueViolation(err, "collections_user_id_name_idx") {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: "You already have a collection with this name",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating collection: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newCollectionJSON(collection))
+}
+
+// handleGetCollections lists the caller's collections in their chosen
+// order. There are at most maxCollections, so the list is not paginated.
+func (cfg *apiConfig) handleGetCollections(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	cfg.writeCollections(w, req, userID)
+}
+
+func (cfg *apiConfig) handleRenameCollection(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	collectionID, err := uuid.Parse(req.PathValue("collectionID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "collectionID must be a valid UUID",
+		})
+		return
+	}
+
+	name, ok := decodeCollectionName(w, req)
+	if !ok {
+		return
+	}
+
+	if _, ok := cfg.ownCollection(w, req, userID, collectionID); !ok {
+		return
+	}
+
+	collection, err := cfg.db.RenameCollection(req.Context(), database.RenameCollectionParams{
+		ID:     collectionID,
+		UserID: userID,
+		Name:   name,
+	})
+	if isUniqueViolation(err, "collections_user_id_name_idx") {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: "You already have a collection with this name",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error renaming collection: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newCollectionJSON(collection))
+}
+
+// handleDeleteCollection removes a collection. Its bookmarks are kept,
+// outside any collection.
+func (cfg *apiConfig) handleDeleteCollection(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	collectionID, err := uuid.Parse(req.PathValue("collectionID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "collectionID must be a valid UUID",
+		})
+		return
+	}
+
+	deleted, err := cfg.db.DeleteCollection(req.Context(), database.DeleteCollectionParams{
+		ID:     collectionID,
+		UserID: userID,
+	})
+	if err != nil {
+		log.Printf("Error deleting collection: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	if deleted == 0 {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Collection not found",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleReorderCollections takes every one of the caller's collection
+// IDs in the new order and renumbers the collections to match.
+func (cfg *apiConfig) handleReorderCollections(w http.ResponseWriter, req *http.Request) {
+	type incoming struct {
+		CollectionIDs []uuid.UUID `json:"collection_ids"`
+	}
+
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+
+	err = cfg.reorderCollections(req.Context(), userID, incomingJSON.CollectionIDs)
+	if errors.Is(err, errCollectionOrder) {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "collection_ids must list each of your collections exactly once",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error reordering collections: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	cfg.writeCollections(w, req, userID)
+}
+
+var errCollectionOrder = errors.New("order does not match the user's collections")
+
+// reorderCollections renumbers userID's collections in the order of ids.
+// The collections are locked first, so two reorders cannot interleave.
+func (cfg *apiConfig) reorderCollections(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) error {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	collections, err := qtx.LockCollectionsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	remaining := map[uuid.UUID]bool{}
+	for _, collection := range collections {
+		remaining[collection.ID] = true
+	}
+	if len(ids) != len(remaining) {
+		return errCollectionOrder
+	}
+	for _, id := range ids {
+		if !remaining[id] {
+			return errCollectionOrder
+		}
+		delete(remaining, id)
+	}
+
+	for i, id := range ids {
+		err := qtx.SetCollectionPosition(ctx, database.SetCollectionPositionParams{
+			ID:       id,
+			UserID:   userID,
+			Position: int32(i),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// writeCollections writes userID's collections as a JSON array.
+func (cfg *apiConfig) writeCollections(w http.ResponseWriter, req *http.Request, userID uuid.UUID) {
+	collections, err := cfg.db.ListCollectionsByUser(req.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching collections: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	collectionsJSON := []collectionJSON{}
+	for _, collection := range collections {
+		collectionsJSON = append(collectionsJSON, newCollectionJSON(collection))
+	}
+	writeJSON(w, http.StatusOK, collectionsJSON)
+}
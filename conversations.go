@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/chirptext"
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxConversationMembers includes the user who starts the
+	// conversation.
+	maxConversationMembers          = 10
+	maxConversationNameLength       = 50
+	conversationDirectKeyConstraint = "conversations_direct_key_key"
+)
+
+// conversationJSON is a conversation as seen by one of its members.
+// UnreadCount only counts messages by the other members.
+type conversationJSON struct {
+	ID          uuid.UUID         `json:"id"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Name        string            `json:"name"`
+	IsGroup     bool              `json:"is_group"`
+	Members     []userSummaryJSON `json:"members"`
+	UnreadCount int64             `json:"unread_count"`
+}
+
+// directKey identifies the one-to-one conversation between a and b,
+// whichever of them starts it.
+func directKey(a, b uuid.UUID) string {
+	keys := []string{a.String(), b.String()}
+	slices.Sort(keys)
+	return strings.Join(keys, ":")
+}
+
+// renderConversations converts conversations to their JSON form, loading
+// the members of all of them in one query.
+func (cfg *apiConfig) renderConversations(ctx context.Context, conversations []database.Conversation, unread map[uuid.UUID]int64) ([]conversationJSON, error) {
+	conversationsJSON := []conversationJSON{}
+	if len(conversations) == 0 {
+		return conversationsJSON, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(conversations))
+	for _, conversation := range conversations {
+		ids = append(ids, conversation.ID)
+	}
+
+	rows, err := cfg.db.ListConversationMembers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	members := map[uuid.UUID][]userSummaryJSON{}
+	for _, row := range rows {
+		members[row.ConversationID] = append(members[row.ConversationID], userSummaryJSON{
+			ID:     row.ID,
+			Handle: nullStringPtr(row.Handle),
+		})
+	}
+
+	for _, conversation := range conversations {
+		conversationsJSON = append(conversationsJSON, conversationJSON{
+			ID:          conversation.ID,
+			CreatedAt:   conversation.CreatedAt,
+			UpdatedAt:   conversation.UpdatedAt,
+			Name:        conversation.Name,
+			IsGroup:     !conversation.DirectKey.Valid,
+			Members:     members[conversation.ID],
+			UnreadCount: unread[conversation.ID],
+		})
+	}
+	return conversationsJSON, nil
+}
+
+// handleCreateConversation starts a conversation between the caller and
+// user_ids. With a single other user it is their one-to-one conversation,
+// which is returned with 200 if it already exists; with more it is a new
+// group.
+func (cfg *apiConfig) handleCreateConversation(w http.ResponseWriter, req *http.Request) {
+	type incoming struct {
+		UserIDs []uuid.UUID `json:"user_ids"`
+		Name    string      `json:"name"`
+	}
+
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+
+	others := []uuid.UUID{}
+	for _, id := range incomingJSON.UserIDs {
+		if id == userID {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "user_ids must not include yourself",
+			})
+			return
+		}
+		if !slices.Contains(others, id) {
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 || len(others) >= maxConversationMembers {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: fmt.Sprintf("user_ids must list between 1 and %d other users", maxConversationMembers-1),
+		})
+		return
+	}
+
+	name := strings.TrimSpace(incomingJSON.Name)
+	if name != "" {
+		if len(others) == 1 {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "Only group conversations can have a name",
+			})
+			return
+		}
+		if chirptext.Length(name) > maxConversationNameLength {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: fmt.Sprintf("name must be at most %d characters", maxConversationNameLength),
+			})
+			return
+		}
+		checked := cfg.moderate(name)
+		if checked.Rejected {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "Name contains language that is not allowed",
+			})
+			return
+		}
+		name = checked.Text
+	}
+
+	users, err := cfg.db.GetUsersByIDs(req.Context(), others)
+	if err != nil {
+		log.Printf("Error fetching users: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	if len(users) != len(others) {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "user_ids must reference existing users",
+		})
+		return
+	}
+
+	blocked, err := cfg.db.ListBlockedEitherWay(req.Context(), userID)
+	if err != nil {
+		log.Printf("Error checking blocks: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	for _, id := range others {
+		if slices.Contains(blocked, id) {
+			writeJSON(w, http.StatusForbidden, errorJSON{
+				Error: "You cannot message this user",
+			})
+			return
+		}
+	}
+
+	status := http.StatusCreated
+	conversation, err := cfg.createConversation(req.Context(), userID, others, name)
+	if isUniqueViolation(err, conversationDirectKeyConstraint) {
+		status = http.StatusOK
+		conversation, err = cfg.db.GetConversationByDirectKey(req.Context(), sql.NullString{
+			String: directKey(userID, others[0]),
+			Valid:  true,
+		})
+	}
+	if err != nil {
+		log.Printf("Error creating conversation: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	conversationsJSON, err := cfg.renderConversations(req.Context(), []database.Conversation{conversation}, nil)
+	if err != nil {
+		log.Printf("Error rendering conversation: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, status, conversationsJSON[0])
+}
+
+// createConversation stores a conversation and its members in one
+// transaction. A one-to-one conversation that already exists fails with a
+// unique violation on conversationDirectKeyConstraint.
+func (cfg *apiConfig) createConversation(ctx context.Context, userID uuid.UUID, others []uuid.UUID, name string) (database.Conversation, error) {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Conversation{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	key := sql.NullString{}
+	if len(others) == 1 {
+		key = sql.NullString{String: directKey(userID, others[0]), Valid: true}
+	}
+
+	conversation, err := qtx.CreateConversation(ctx, database.CreateConversationParams{
+		Name:      name,
+		DirectKey: key,
+	})
+	if err != nil {
+		return database.Conversation{}, err
+	}
+
+	for _, memberID := range append([]uuid.UUID{userID}, others...) {
+		err := qtx.CreateConversationMember(ctx, database.CreateConversationMemberParams{
+			ConversationID: conversation.ID,
+			UserID:         memberID,
+		})
+		if err != nil {
+			return database.Conversation{}, err
+		}
+	}
+
+	return conversation, tx.Commit()
+}
+
+// handleGetConversations lists the caller's conversations, most recently
+// active first.
+func (cfg *apiConfig) handleGetConversations(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	rows, err := cfg.db.ListConversationsForUser(req.Context(), database.ListConversationsForUserParams{
+		UserID:          userID,
+		BeforeUpdatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		MaxRows:         int32(limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error fetching conversations: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1].Conversation
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.UpdatedAt, ID: last.ID})
+	}
+
+	conversations := make([]database.Conversation, 0, len(rows))
+	unread := map[uuid.UUID]int64{}
+	for _, row := range rows {
+		conversations = append(conversations, row.Conversation)
+		unread[row.Conversation.ID] = row.UnreadCount
+	}
+
+	conversationsJSON, err := cfg.renderConversations(req.Context(), conversations, unread)
+	if err != nil {
+		log.Printf("Error rendering conversations: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, conversationsJSON)
+}
+
+// handleMarkConversationRead marks every message in a conversation so far
+// as read by the caller.
+func (cfg *apiConfig) handleMarkConversationRead(w http.ResponseWriter, req *http.Request) {
+	userID, conversation, ok := cfg.authorizeConversation(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.MarkConversationRead(req.Context(), database.MarkConversationReadParams{
+		ConversationID: conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		log.Printf("Error marking conversation read: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeConversation authenticates the caller and fetches the
+// conversation in the path. Conversations the caller is not a member of
+// are reported as not found. It writes the error response itself when ok
+// is false.
+func (cfg *apiConfig) authorizeConversation(w http.ResponseWriter, req *http.Request) (userID uuid.UUID, conversation database.Conversation, ok bool) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return uuid.Nil, database.Conversation{}, false
+	}
+
+	conversationID, err := uuid.Parse(req.PathValue("conversationID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "conversationID must be a valid UUID",
+		})
+		return uuid.Nil, database.Conversation{}, false
+	}
+
+	conversation, err = cfg.db.GetConversationForMember(req.Context(), database.GetConversationForMemberParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Conversation not found",
+		})
+		return uuid.Nil, database.Conversation{}, false
+	}
+	if err != nil {
+		log.Printf("Error fetching conversation: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return uuid.Nil, database.Conversation{}, false
+	}
+
+	return userID, conversation, true
+}
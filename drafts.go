@@ -0,0 +1,460 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	draftPublishInterval = 15 * time.Second
+	// draftRetryDelay is how long a scheduled draft that failed to
+	// publish waits before it is tried again.
+	draftRetryDelay = 5 * time.Minute
+)
+
+// draftJSON is an unpublished chirp. Only its author ever sees it; a
+// draft with PublishAt set is published by runDraftPublisher when due.
+type draftJSON struct {
+	ID               uuid.UUID   `json:"id"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+	Body             string      `json:"body"`
+	UserID           uuid.UUID   `json:"user_id"`
+	InReplyTo        *uuid.UUID  `json:"in_reply_to"`
+	Kind             string      `json:"kind"`
+	ReferenceChirpID *uuid.UUID  `json:"reference_chirp_id"`
+	MediaIDs         []uuid.UUID `json:"media_ids"`
+	PublishAt        *time.Time  `json:"publish_at"`
+}
+
+func newDraftJSON(draft database.Draft) draftJSON {
+	d := draftJSON{
+		ID:        draft.ID,
+		CreatedAt: draft.CreatedAt,
+		UpdatedAt: draft.UpdatedAt,
+		Body:      draft.Body,
+		UserID:    draft.UserID,
+		Kind:      draft.Kind,
+		MediaIDs:  draft.MediaIds,
+	}
+	if draft.InReplyTo.Valid {
+		d.InReplyTo = &draft.InReplyTo.UUID
+	}
+	if draft.ReferenceChirpID.Valid {
+		d.ReferenceChirpID = &draft.ReferenceChirpID.UUID
+	}
+	if draft.PublishAt.Valid {
+		d.PublishAt = &draft.PublishAt.Time
+	}
+	return d
+}
+
+// draftInput turns a stored draft back into the input it was made from,
+// so that it is validated again when it is published.
+func draftInput(draft database.Draft) chirpInput {
+	input := chirpInput{
+		Body:     draft.Body,
+		Kind:     draft.Kind,
+		MediaIDs: draft.MediaIds,
+	}
+	if draft.InReplyTo.Valid {
+		input.InReplyTo = &draft.InReplyTo.UUID
+	}
+	if draft.ReferenceChirpID.Valid {
+		input.ReferenceChirpID = &draft.ReferenceChirpID.UUID
+	}
+	return input
+}
+
+type draftIncoming struct {
+	chirpInput
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// decodeDraft reads and validates a draft from the request body. On
+// failure it has already written the response.
+func (cfg *apiConfig) decodeDraft(w http.ResponseWriter, req *http.Request, userID uuid.UUID) (newChirp, sql.NullTime, bool) {
+	incomingJSON := draftIncoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return newChirp{}, sql.NullTime{}, false
+	}
+
+	if incomingJSON.Poll != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Polls cannot be scheduled",
+		})
+		return newChirp{}, sql.NullTime{}, false
+	}
+
+	publishAt := sql.NullTime{}
+	if incomingJSON.PublishAt != nil {
+		if !incomingJSON.PublishAt.After(time.Now()) {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "publish_at must be in the future",
+			})
+			return newChirp{}, sql.NullTime{}, false
+		}
+		publishAt = sql.NullTime{Time: incomingJSON.PublishAt.UTC(), Valid: true}
+	}
+
+	chirp, status, msg := cfg.prepareChirp(req.Context(), userID, incomingJSON.chirpInput)
+	if msg != "" {
+		writeJSON(w, status, errorJSON{
+			Error: msg,
+		})
+		return newChirp{}, sql.NullTime{}, false
+	}
+
+	return chirp, publishAt, true
+}
+
+func (cfg *apiConfig) handleCreateDraft(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	chirp, publishAt, ok := cfg.decodeDraft(w, req, userID)
+	if !ok {
+		return
+	}
+
+	draft, err := cfg.db.CreateDraft(req.Context(), database.CreateDraftParams{
+		UserID:           chirp.UserID,
+		Body:             chirp.Body,
+		InReplyTo:        chirp.InReplyTo,
+		Kind:             chirp.Kind,
+		ReferenceChirpID: chirp.ReferenceChirpID,
+		PublishAt:        publishAt,
+		MediaIds:         chirp.MediaIDs,
+	})
+	if err != nil {
+		log.Printf("Error creating draft: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newDraftJSON(draft))
+}
+
+func (cfg *apiConfig) handleGetDrafts(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	drafts, err := cfg.db.ListDraftsByUser(req.Context(), database.ListDraftsByUserParams{
+		UserID:          userID,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		MaxRows:         int32(limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error listing drafts: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(drafts) > limit {
+		drafts = drafts[:limit]
+		last := drafts[limit-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	draftsJSON := []draftJSON{}
+	for _, draft := range drafts {
+		draftsJSON = append(draftsJSON, newDraftJSON(draft))
+	}
+
+	writeJSON(w, http.StatusOK, draftsJSON)
+}
+
+// ownDraft loads the draft named in the path for its author. Other users
+// get the same 404 as for a draft that does not exist.
+func (cfg *apiConfig) ownDraft(w http.ResponseWriter, req *http.Request) (database.Draft, bool) {
+	draftID, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "draftID must be a valid UUID",
+		})
+		return database.Draft{}, false
+	}
+
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return database.Draft{}, false
+	}
+
+	draft, err := cfg.db.GetDraftByID(req.Context(), draftID)
+	if err != nil || draft.UserID != userID {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Draft not found",
+		})
+		return database.Draft{}, false
+	}
+
+	return draft, true
+}
+
+func (cfg *apiConfig) handleGetDraft(w http.ResponseWriter, req *http.Request) {
+	draft, ok := cfg.ownDraft(w, req)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newDraftJSON(draft))
+}
+
+func (cfg *apiConfig) handleUpdateDraft(w http.ResponseWriter, req *http.Request) {
+	draft, ok := cfg.ownDraft(w, req)
+	if !ok {
+		return
+	}
+
+	chirp, publishAt, ok := cfg.decodeDraft(w, req, draft.UserID)
+	if !ok {
+		return
+	}
+
+	draft, err := cfg.db.UpdateDraft(req.Context(), database.UpdateDraftParams{
+		ID:               draft.ID,
+		Body:             chirp.Body,
+		InReplyTo:        chirp.InReplyTo,
+		Kind:             chirp.Kind,
+		ReferenceChirpID: chirp.ReferenceChirpID,
+		PublishAt:        publishAt,
+		MediaIds:         chirp.MediaIDs,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Published by the scheduler while this request was running.
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Draft not found",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating draft: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newDraftJSON(draft))
+}
+
+func (cfg *apiConfig) handleDeleteDraft(w http.ResponseWriter, req *http.Request) {
+	draft, ok := cfg.ownDraft(w, req)
+	if !ok {
+		return
+	}
+
+	if err := cfg.db.DeleteDraft(req.Context(), draft.ID); err != nil {
+		log.Printf("Error deleting draft: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePublishDraft publishes a draft right away. The row lock makes it
+// wait for, or be skipped by, a scheduler publishing the same draft.
+func (cfg *apiConfig) handlePublishDraft(w http.ResponseWriter, req *http.Request) {
+	draft, ok := cfg.ownDraft(w, req)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.sqlDB.BeginTx(req.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	draft, err = qtx.LockDraft(req.Context(), draft.ID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Draft not found",
+		})
+		return
+	}
+
+	chirp, status, msg := cfg.prepareChirp(req.Context(), draft.UserID, draftInput(draft))
+	if msg != "" {
+		writeJSON(w, status, errorJSON{
+			Error: msg,
+		})
+		return
+	}
+
+	published, err := publishDraft(req.Context(), qtx, draft.ID, chirp)
+	if isUniqueViolation(err, "chirps_user_id_rechirp_idx") {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: "Chirp is already rechirped",
+		})
+		return
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("Error publishing draft: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	cfg.notifyChirp(req.Context(), published)
+	cfg.writeChirp(w, req, http.StatusCreated, published)
+}
+
+// publishDraft creates the chirp for a locked draft and deletes the
+// draft, both inside the caller's transaction.
+func publishDraft(ctx context.Context, qtx *database.Queries, draftID uuid.UUID, c newChirp) (database.Chirp, error) {
+	chirp, err := insertChirp(ctx, qtx, c)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	return chirp, qtx.DeleteDraft(ctx, draftID)
+}
+
+// runDraftPublisher publishes scheduled drafts once they are due,
+// checking every draftPublishInterval until ctx is cancelled. Each draft
+// is claimed with FOR UPDATE SKIP LOCKED, so several instances can run
+// this against the same database without publishing a draft twice.
+func (cfg *apiConfig) runDraftPublisher(ctx context.Context) {
+	ticker := time.NewTicker(draftPublishInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			found, err := cfg.publishDueDraft(ctx)
+			if err != nil {
+				log.Printf("Error publishing scheduled draft: %s", err)
+			}
+			if !found {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDueDraft publishes the oldest due draft, reporting whether there
+// was one. A draft that is no longer valid, for example because the chirp
+// it replies to was deleted, is unscheduled and left for its author. A
+// failure on our side holds the draft back for draftRetryDelay, so the
+// drafts due after it still go out.
+func (cfg *apiConfig) publishDueDraft(ctx context.Context) (bool, error) {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	draft, err := qtx.ClaimDueDraft(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := cfg.publishClaimedDraft(ctx, tx, qtx, draft); err != nil {
+		tx.Rollback()
+		deferErr := cfg.db.DeferDraft(ctx, database.DeferDraftParams{
+			ID:      draft.ID,
+			RetryAt: sql.NullTime{Time: time.Now().Add(draftRetryDelay), Valid: true},
+		})
+		if deferErr != nil {
+			return false, deferErr
+		}
+		return true, fmt.Errorf("publishing draft %s: %w", draft.ID, err)
+	}
+	return true, nil
+}
+
+// publishClaimedDraft publishes or unschedules a draft locked by tx, and
+// commits tx.
+func (cfg *apiConfig) publishClaimedDraft(ctx context.Context, tx *sql.Tx, qtx *database.Queries, draft database.Draft) error {
+	chirp, status, msg := cfg.prepareChirp(ctx, draft.UserID, draftInput(draft))
+	if status >= http.StatusInternalServerError {
+		// Not the draft's fault: leave it scheduled and retry later.
+		return errors.New(msg)
+	}
+	if msg != "" {
+		log.Printf("Unscheduling draft %s: %s", draft.ID, msg)
+		if err := qtx.UnscheduleDraft(ctx, draft.ID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	published, err := publishDraft(ctx, qtx, draft.ID, chirp)
+	if isUniqueViolation(err, "chirps_user_id_rechirp_idx") {
+		// The failed insert aborted the transaction, so unschedule the
+		// draft outside of it.
+		tx.Rollback()
+		log.Printf("Unscheduling draft %s: chirp is already rechirped", draft.ID)
+		return cfg.db.UnscheduleDraft(ctx, draft.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	cfg.notifyChirp(ctx, published)
+	return nil
+}
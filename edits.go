@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+var errChirpDeleted = errors.New("chirp was deleted")
+
+type revisionJSON struct {
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (cfg *apiConfig) handleUpdateChirp(w http.ResponseWriter, req *http.Request) {
+	type incoming struct {
+		Body string `json:"body"`
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "chirpID must be a valid UUID",
+		})
+		return
+	}
+
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpByID(req.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+
+	if chirp.UserID != userID {
+		writeJSON(w, http.StatusForbidden, errorJSON{
+			Error: "Forbidden",
+		})
+		return
+	}
+
+	if chirp.Kind == chirpKindRechirp {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Rechirps cannot be edited",
+		})
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(req.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching user: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	window := cfg.editWindow
+	if user.IsChirpyRed {
+		window = cfg.redEditWindow
+	}
+	if time.Since(chirp.CreatedAt) > window {
+		writeJSON(w, http.StatusForbidden, errorJSON{
+			Error: fmt.Sprintf("Chirps can only be edited within %s of posting", window),
+		})
+		return
+	}
+
+	if chirp.Kind == chirpKindQuote && incomingJSON.Body == "" {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "A quote needs a body",
+		})
+		return
+	}
+
+	checked, msg := cfg.validateChirp(incomingJSON.Body, cfg.chirpLengthLimit(user))
+	if msg != "" {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: msg,
+		})
+		return
+	}
+
+	if checked.Text == chirp.Body {
+		cfg.writeChirp(w, req, http.StatusOK, chirp)
+		return
+	}
+
+	chirp, err = cfg.editChirp(req.Context(), chirp, checked)
+	if errors.Is(err, errChirpDeleted) {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error editing chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	cfg.notifyChirp(req.Context(), chirp)
+	cfg.writeChirp(w, req, http.StatusOK, chirp)
+}
+
+// editChirp replaces a chirp's body with the moderated text, keeping the
+// previous one as a revision, relinking hashtags and mentions to the new
+// text and flagging it if the rules ask for review.
+func (cfg *apiConfig) editChirp(ctx context.Context, chirp database.Chirp, checked moderation.Result) (database.Chirp, error) {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Reread the body under a row lock, so a concurrent edit cannot slip
+	// in between and have its text left out of the revisions, nor a
+	// delete leave the edit on a tombstone.
+	chirp, err = qtx.LockChirp(ctx, chirp.ID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if chirp.DeletedAt.Valid {
+		return database.Chirp{}, errChirpDeleted
+	}
+
+	err = qtx.CreateChirpRevision(ctx, database.CreateChirpRevisionParams{
+		ChirpID: chirp.ID,
+		Body:    chirp.Body,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	edited, err := qtx.UpdateChirpBody(ctx, database.UpdateChirpBodyParams{
+		ID:   chirp.ID,
+		Body: checked.Text,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := qtx.DeleteChirpHashtags(ctx, chirp.ID); err != nil {
+		return database.Chirp{}, err
+	}
+	if err := qtx.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+		return database.Chirp{}, err
+	}
+	if err := linkChirpBody(ctx, qtx, edited); err != nil {
+		return database.Chirp{}, err
+	}
+	if err := flagChirp(ctx, qtx, edited.ID, checked.Matched); err != nil {
+		return database.Chirp{}, err
+	}
+
+	return edited, tx.Commit()
+}
+
+func (cfg *apiConfig) handleGetChirpRevisions(w http.ResponseWriter, req *http.Request) {
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "chirpID must be a valid UUID",
+		})
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpByID(req.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+
+	// Rendering applies the same block and mute rules as reading the
+	// chirp itself.
+	visible, err := cfg.renderChirps(req.Context(), cfg.viewerID(req), []database.Chirp{chirp})
+	if err != nil {
+		log.Printf("Error rendering chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	if len(visible) == 0 {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+
+	revisions, err := cfg.db.ListChirpRevisions(req.Context(), chirpID)
+	if err != nil {
+		log.Printf("Error fetching chirp revisions: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	revisionsJSON := []revisionJSON{}
+	for _, revision := range revisions {
+		revisionsJSON = append(revisionsJSON, revisionJSON{
+			Body:      revision.Body,
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, revisionsJSON)
+}
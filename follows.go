@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type followJSON struct {
+	userSummaryJSON
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (cfg *apiConfig) handleFollowUser(w http.ResponseWriter, req *http.Request) {
+	followerID, followeeID, ok := cfg.authorizeFollow(w, req)
+	if !ok {
+		return
+	}
+
+	followed, err := cfg.db.CreateFollow(req.Context(), database.CreateFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		log.Printf("Error following user: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	if followed > 0 {
+		cfg.notify(req.Context(), followNotification(followerID, followeeID))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleUnfollowUser(w http.ResponseWriter, req *http.Request) {
+	followerID, followeeID, ok := cfg.authorizeFollow(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.DeleteFollow(req.Context(), database.DeleteFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		log.Printf("Error unfollowing user: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeFollow authenticates the caller and checks that the user in the
+// path exists and is someone else. It writes the error response itself
+// when ok is false.
+func (cfg *apiConfig) authorizeFollow(w http.ResponseWriter, req *http.Request) (followerID, followeeID uuid.UUID, ok bool) {
+	followerID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	followeeID, err = uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "userID must be a valid UUID",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if followeeID == followerID {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "You cannot follow yourself",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if _, err := cfg.db.GetUserByID(req.Context(), followeeID); err != nil {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "User not found",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if req.Method == http.MethodPost && cfg.blockedEitherWay(req.Context(), followerID, followeeID) {
+		writeJSON(w, http.StatusForbidden, errorJSON{
+			Error: "You cannot follow this user",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return followerID, followeeID, true
+}
+
+func (cfg *apiConfig) handleGetFollowers(w http.ResponseWriter, req *http.Request) {
+	cfg.writeFollowList(w, req, func(ctx context.Context, userID uuid.UUID, cursor pagination.Cursor, limit int) ([]followJSON, error) {
+		rows, err := cfg.db.ListFollowers(ctx, database.ListFollowersParams{
+			UserID:          userID,
+			BeforeCreatedAt: cursor.CreatedAt,
+			BeforeID:        cursor.ID,
+			MaxRows:         int32(limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		follows := []followJSON{}
+		for _, row := range rows {
+			follows = append(follows, followJSON{
+				userSummaryJSON: userSummaryJSON{ID: row.ID, Handle: nullStringPtr(row.Handle)},
+				FollowedAt:      row.FollowedAt,
+			})
+		}
+		return follows, nil
+	})
+}
+
+func (cfg *apiConfig) handleGetFollowing(w http.ResponseWriter, req *http.Request) {
+	cfg.writeFollowList(w, req, func(ctx context.Context, userID uuid.UUID, cursor pagination.Cursor, limit int) ([]followJSON, error) {
+		rows, err := cfg.db.ListFollowing(ctx, database.ListFollowingParams{
+			UserID:          userID,
+			BeforeCreatedAt: cursor.CreatedAt,
+			BeforeID:        cursor.ID,
+			MaxRows:         int32(limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		follows := []followJSON{}
+		for _, row := range rows {
+			follows = append(follows, followJSON{
+				userSummaryJSON: userSummaryJSON{ID: row.ID, Handle: nullStringPtr(row.Handle)},
+				FollowedAt:      row.FollowedAt,
+			})
+		}
+		return follows, nil
+	})
+}
+
+// writeFollowList pages through one side of the follow graph of the user
+// in the path, newest follow first.
+func (cfg *apiConfig) writeFollowList(w http.ResponseWriter, req *http.Request, list func(context.Context, uuid.UUID, pagination.Cursor, int) ([]followJSON, error)) {
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "userID must be a valid UUID",
+		})
+		return
+	}
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	follows, err := list(req.Context(), userID, cursor, limit+1)
+	if err != nil {
+		log.Printf("Error fetching follows: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(follows) > limit {
+		follows = follows[:limit]
+		last := follows[limit-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.FollowedAt, ID: last.ID})
+	}
+
+	writeJSON(w, http.StatusOK, follows)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+)
+
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 7 * 24 * time.Hour
+	defaultTrendingLimit  = 10
+)
+
+func (cfg *apiConfig) handleGetHashtagChirps(w http.ResponseWriter, req *http.Request) {
+	tag := strings.ToLower(strings.TrimPrefix(req.PathValue("tag"), "#"))
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	chirps, err := cfg.db.ListChirpsByHashtag(req.Context(), database.ListChirpsByHashtagParams{
+		Tag:             tag,
+		ViewerID:        cfg.viewerID(req),
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		MaxRows:         int32(limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error fetching chirps for hashtag: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		last := chirps[limit-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	cfg.writeChirps(w, req, http.StatusOK, chirps)
+}
+
+func (cfg *apiConfig) handleGetTrendingHashtags(w http.ResponseWriter, req *http.Request) {
+	type trendingJSON struct {
+		Tag  string `json:"tag"`
+		Uses int64  `json:"uses"`
+	}
+
+	window := defaultTrendingWindow
+	if windowStr := req.URL.Query().Get("window"); windowStr != "" {
+		parsed, err := time.ParseDuration(windowStr)
+		if err != nil || parsed <= 0 || parsed > maxTrendingWindow {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "window must be a duration between 0 and 168h",
+			})
+			return
+		}
+		window = parsed
+	}
+
+	limit := defaultTrendingLimit
+	if limitStr := req.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > pagination.MaxLimit {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "limit must be between 1 and 100",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	rows, err := cfg.db.GetTrendingHashtags(req.Context(), database.GetTrendingHashtagsParams{
+		Since:   time.Now().Add(-window),
+		MaxRows: int32(limit),
+	})
+	if err != nil {
+		log.Printf("Error fetching trending hashtags: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	trending := []trendingJSON{}
+	for _, row := range rows {
+		trending = append(trending, trendingJSON{
+			Tag:  row.Tag,
+			Uses: row.Uses,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, trending)
+}
@@ -0,0 +1,22 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid blob key")
+	ErrNotFound   = errors.New("blob not found")
+)
+
+// BlobStore keeps uploaded files under opaque keys and knows the public
+// URL each one is served from. The local filesystem is the only backend
+// today; an S3-compatible one only needs to satisfy the same interface.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
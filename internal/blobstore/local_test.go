@@ -0,0 +1,68 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocal_PutAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocal(dir, "/media/")
+	if err != nil {
+		t.Fatalf("NewLocal returned error: %v", err)
+	}
+
+	if err := store.Put(context.Background(), "abc.png", strings.NewReader("data")); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "abc.png"))
+	if err != nil || string(got) != "data" {
+		t.Fatalf("Expected stored blob %q, got %q (%v)", "data", got, err)
+	}
+
+	rc, err := store.Get(context.Background(), "abc.png")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	got, err = io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(got) != "data" {
+		t.Fatalf("Expected to read %q, got %q (%v)", "data", got, err)
+	}
+
+	if url := store.URL("abc.png"); url != "/media/abc.png" {
+		t.Errorf("Expected URL /media/abc.png, got %s", url)
+	}
+
+	if err := store.Delete(context.Background(), "abc.png"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "abc.png")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected blob to be deleted, got %v", err)
+	}
+	if err := store.Delete(context.Background(), "abc.png"); err != nil {
+		t.Errorf("Deleting a missing blob returned error: %v", err)
+	}
+	if _, err := store.Get(context.Background(), "abc.png"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a deleted blob, got %v", err)
+	}
+}
+
+func TestLocal_InvalidKey(t *testing.T) {
+	store, err := NewLocal(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatalf("NewLocal returned error: %v", err)
+	}
+
+	for _, key := range []string{"", ".", "..", "../escape", "a/b", `a\b`} {
+		if err := store.Put(context.Background(), key, strings.NewReader("data")); err != ErrInvalidKey {
+			t.Errorf("Put(%q): expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+}
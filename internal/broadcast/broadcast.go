@@ -0,0 +1,76 @@
+package broadcast
+
+import "sync"
+
+// Hub fans values out to every current subscriber. Publish never blocks:
+// a subscriber that falls more than its buffer behind is dropped, which
+// closes its channel, and it is up to the reader to resubscribe and catch
+// up some other way.
+type Hub[T any] struct {
+	mu   sync.Mutex
+	subs map[chan T]struct{}
+}
+
+func New[T any]() *Hub[T] {
+	return &Hub[T]{subs: map[chan T]struct{}{}}
+}
+
+// Subscribe returns a channel receiving every value published from now
+// on, and a function that unsubscribes. The channel is closed once the
+// subscriber unsubscribes or is dropped. Calling the function again does
+// nothing.
+func (h *Hub[T]) Subscribe(buffer int) (<-chan T, func()) {
+	ch := make(chan T, buffer)
+
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(ch)
+	}
+}
+
+// Publish sends v to every subscriber, dropping those whose buffer is
+// full.
+func (h *Hub[T]) Publish(v T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs {
+		select {
+		case ch <- v:
+		default:
+			h.remove(ch)
+		}
+	}
+}
+
+// DropAll drops every current subscriber, for when they may have missed
+// values and have to catch up some other way.
+func (h *Hub[T]) DropAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs {
+		h.remove(ch)
+	}
+}
+
+// Len is the number of current subscribers.
+func (h *Hub[T]) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// remove closes ch and forgets it. h.mu must be held.
+func (h *Hub[T]) remove(ch chan T) {
+	if _, ok := h.subs[ch]; !ok {
+		return
+	}
+	delete(h.subs, ch)
+	close(ch)
+}
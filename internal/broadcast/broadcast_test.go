@@ -0,0 +1,90 @@
+package broadcast
+
+import "testing"
+
+func TestHub_PublishReachesEverySubscriber(t *testing.T) {
+	hub := New[int]()
+	a, cancelA := hub.Subscribe(1)
+	defer cancelA()
+	b, cancelB := hub.Subscribe(1)
+	defer cancelB()
+
+	hub.Publish(7)
+
+	for name, ch := range map[string]<-chan int{"a": a, "b": b} {
+		select {
+		case got := <-ch:
+			if got != 7 {
+				t.Errorf("Expected subscriber %s to receive 7, got %d", name, got)
+			}
+		default:
+			t.Errorf("Expected subscriber %s to receive a value", name)
+		}
+	}
+}
+
+func TestHub_Unsubscribe(t *testing.T) {
+	hub := New[int]()
+	ch, cancel := hub.Subscribe(1)
+
+	cancel()
+	cancel()
+
+	if _, ok := <-ch; ok {
+		t.Error("Expected the channel to be closed after unsubscribing")
+	}
+	if n := hub.Len(); n != 0 {
+		t.Errorf("Expected no subscribers, got %d", n)
+	}
+
+	// Publishing with nobody listening must not panic on the closed
+	// channel.
+	hub.Publish(1)
+}
+
+func TestHub_DropsSlowSubscriber(t *testing.T) {
+	hub := New[int]()
+	slow, cancelSlow := hub.Subscribe(1)
+	defer cancelSlow()
+	fast, cancelFast := hub.Subscribe(2)
+	defer cancelFast()
+
+	hub.Publish(1)
+	hub.Publish(2)
+
+	if got := <-slow; got != 1 {
+		t.Errorf("Expected the slow subscriber to keep its buffered value 1, got %d", got)
+	}
+	if _, ok := <-slow; ok {
+		t.Error("Expected the slow subscriber's channel to be closed")
+	}
+
+	for _, want := range []int{1, 2} {
+		if got := <-fast; got != want {
+			t.Errorf("Expected the fast subscriber to receive %d, got %d", want, got)
+		}
+	}
+	if n := hub.Len(); n != 1 {
+		t.Errorf("Expected one subscriber left, got %d", n)
+	}
+}
+
+func TestHub_DropAll(t *testing.T) {
+	hub := New[int]()
+	a, cancelA := hub.Subscribe(1)
+	b, _ := hub.Subscribe(1)
+
+	hub.DropAll()
+
+	for name, ch := range map[string]<-chan int{"a": a, "b": b} {
+		if _, ok := <-ch; ok {
+			t.Errorf("Expected subscriber %s's channel to be closed", name)
+		}
+	}
+	if n := hub.Len(); n != 0 {
+		t.Errorf("Expected no subscribers, got %d", n)
+	}
+
+	// Unsubscribing after being dropped must not close the channel again.
+	cancelA()
+}
@@ -0,0 +1,72 @@
+package chirptext
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxTokenLength = 64
+
+// Hashtags returns the distinct #tags in body, lowercased and in the
+// order they first appear. A tag must follow the start of the text or a
+// character that cannot be part of a word, so "a#b" is not a tag, and it
+// must contain at least one non-digit, so "#1" is not either.
+func Hashtags(body string) []string {
+	tags := []string{}
+	for _, tag := range tokens(body, '#') {
+		if !isAllDigits(tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Mentions returns the distinct @handles in body, lowercased and in the
+// order they first appear. The "@" in an address such as a@b.com is not
+// a mention because it follows a word character.
+func Mentions(body string) []string {
+	return tokens(body, '@')
+}
+
+func tokens(body string, sigil rune) []string {
+	found := []string{}
+	seen := map[string]bool{}
+
+	prev := ' '
+	for i, r := range body {
+		if r != sigil || isWordRune(prev) {
+			prev = r
+			continue
+		}
+		prev = r
+
+		rest := body[i+utf8.RuneLen(sigil):]
+		end := strings.IndexFunc(rest, func(r rune) bool { return !isWordRune(r) })
+		if end == -1 {
+			end = len(rest)
+		}
+
+		token := strings.ToLower(rest[:end])
+		if token == "" || utf8.RuneCountInString(token) > maxTokenLength || seen[token] {
+			continue
+		}
+		seen[token] = true
+		found = append(found, token)
+	}
+
+	return found
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isAllDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
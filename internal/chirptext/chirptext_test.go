@@ -0,0 +1,46 @@
+package chirptext
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"no tags here", []string{}},
+		{"#Go is fun", []string{"go"}},
+		{"loving #golang, #Go and #golang again", []string{"golang", "go"}},
+		{"email@example.com a#b #1 #2024", []string{}},
+		{"(#nested) #snake_case! #café", []string{"nested", "snake_case", "café"}},
+		{"#", []string{}},
+	}
+
+	for _, tt := range tests {
+		got := Hashtags(tt.body)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Hashtags(%q): expected %v, got %v", tt.body, tt.want, got)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"hello world", []string{}},
+		{"@Alice and @bob, meet @alice", []string{"alice", "bob"}},
+		{"mail me at me@example.com", []string{}},
+		{"thanks @42!", []string{"42"}},
+	}
+
+	for _, tt := range tests {
+		got := Mentions(tt.body)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Mentions(%q): expected %v, got %v", tt.body, tt.want, got)
+		}
+	}
+}
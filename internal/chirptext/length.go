@@ -0,0 +1,32 @@
+package chirptext
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// URLLength is what every URL counts as towards a chirp's length, however
+// long it really is, so that links do not eat up the limit.
+const URLLength = 23
+
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://\S+`)
+
+// Length measures body the way a reader counts characters: in grapheme
+// clusters, so "é" written with a combining accent or an emoji built from
+// several code points counts once. Each URL counts as URLLength.
+func Length(body string) int {
+	n := 0
+	last := 0
+	for _, loc := range urlPattern.FindAllStringIndex(body, -1) {
+		start, end := loc[0], loc[1]
+		// Punctuation right after a link usually ends the sentence
+		// rather than belonging to the URL.
+		end = start + len(strings.TrimRight(body[start:end], `.,:;!?'")]`))
+
+		n += uniseg.GraphemeClusterCount(body[last:start]) + URLLength
+		last = end
+	}
+	return n + uniseg.GraphemeClusterCount(body[last:])
+}
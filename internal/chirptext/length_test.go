@@ -0,0 +1,42 @@
+package chirptext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		body string
+		want int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"h\u00e9llo", 5},
+		{"he\u0301llo", 5},
+		{"こんにちは", 5},
+		{"👍🏽", 1},
+		{"👨‍👩‍👧‍👦 family", 8},
+		{"🇳🇱🇯🇵", 2},
+		{"see https://example.com/a/very/long/path?with=query", 4 + URLLength},
+		{"(https://example.com).", 1 + URLLength + 2},
+		{"http://a.io and HTTPS://b.io", URLLength + 5 + URLLength},
+		{"not a link: example.com", 23},
+	}
+
+	for _, tt := range tests {
+		if got := Length(tt.body); got != tt.want {
+			t.Errorf("Length(%q): expected %d, got %d", tt.body, tt.want, got)
+		}
+	}
+}
+
+func TestLength_CountsEmojiOnce(t *testing.T) {
+	body := strings.Repeat("😀", 140)
+	if len(body) <= 140 {
+		t.Fatal("expected the body to be longer than 140 bytes")
+	}
+	if got := Length(body); got != 140 {
+		t.Errorf("Expected 140, got %d", got)
+	}
+}
@@ -0,0 +1,252 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: blocks.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createBlock = `-- name: CreateBlock :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING
+`
+
+type CreateBlockParams struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+}
+
+func (q *Queries) CreateBlock(ctx context.Context, arg CreateBlockParams) error {
+	_, err := q.db.ExecContext(ctx, createBlock, arg.BlockerID, arg.BlockedID)
+	return err
+}
+
+const createMute = `-- name: CreateMute :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING
+`
+
+type CreateMuteParams struct {
+	MuterID uuid.UUID
+	MutedID uuid.UUID
+}
+
+func (q *Queries) CreateMute(ctx context.Context, arg CreateMuteParams) error {
+	_, err := q.db.ExecContext(ctx, createMute, arg.MuterID, arg.MutedID)
+	return err
+}
+
+const deleteBlock = `-- name: DeleteBlock :exec
+DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2
+`
+
+type DeleteBlockParams struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+}
+
+func (q *Queries) DeleteBlock(ctx context.Context, arg DeleteBlockParams) error {
+	_, err := q.db.ExecContext(ctx, deleteBlock, arg.BlockerID, arg.BlockedID)
+	return err
+}
+
+const deleteMute = `-- name: DeleteMute :exec
+DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2
+`
+
+type DeleteMuteParams struct {
+	MuterID uuid.UUID
+	MutedID uuid.UUID
+}
+
+func (q *Queries) DeleteMute(ctx context.Context, arg DeleteMuteParams) error {
+	_, err := q.db.ExecContext(ctx, deleteMute, arg.MuterID, arg.MutedID)
+	return err
+}
+
+const isBlockedEitherWay = `-- name: IsBlockedEitherWay :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = $1 AND blocked_id = $2)
+    OR (blocker_id = $2 AND blocked_id = $1)
+)
+`
+
+type IsBlockedEitherWayParams struct {
+	UserA uuid.UUID
+	UserB uuid.UUID
+}
+
+func (q *Queries) IsBlockedEitherWay(ctx context.Context, arg IsBlockedEitherWayParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isBlockedEitherWay, arg.UserA, arg.UserB)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listBlockedEitherWay = `-- name: ListBlockedEitherWay :many
+SELECT (CASE WHEN blocker_id = $1 THEN blocked_id ELSE blocker_id END)::uuid AS other_id
+FROM blocks
+WHERE blocker_id = $1 OR blocked_id = $1
+`
+
+func (q *Queries) ListBlockedEitherWay(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listBlockedEitherWay, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var other_id uuid.UUID
+		if err := rows.Scan(&other_id); err != nil {
+			return nil, err
+		}
+		items = append(items, other_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBlocks = `-- name: ListBlocks :many
+SELECT users.id, users.handle, blocks.created_at
+FROM blocks
+JOIN users ON users.id = blocks.blocked_id
+WHERE blocks.blocker_id = $1
+AND (blocks.created_at, blocks.blocked_id) < ($2::timestamp, $3::uuid)
+ORDER BY blocks.created_at DESC, blocks.blocked_id DESC
+LIMIT $4
+`
+
+type ListBlocksParams struct {
+	UserID          uuid.UUID
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+type ListBlocksRow struct {
+	ID        uuid.UUID
+	Handle    sql.NullString
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListBlocks(ctx context.Context, arg ListBlocksParams) ([]ListBlocksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBlocks,
+		arg.UserID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBlocksRow
+	for rows.Next() {
+		var i ListBlocksRow
+		if err := rows.Scan(&i.ID, &i.Handle, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHiddenUserIDs = `-- name: ListHiddenUserIDs :many
+SELECT DISTINCT hidden_id FROM hidden_users WHERE viewer_id = $1
+`
+
+func (q *Queries) ListHiddenUserIDs(ctx context.Context, viewerID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listHiddenUserIDs, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var hidden_id uuid.UUID
+		if err := rows.Scan(&hidden_id); err != nil {
+			return nil, err
+		}
+		items = append(items, hidden_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMutes = `-- name: ListMutes :many
+SELECT users.id, users.handle, mutes.created_at
+FROM mutes
+JOIN users ON users.id = mutes.muted_id
+WHERE mutes.muter_id = $1
+AND (mutes.created_at, mutes.muted_id) < ($2::timestamp, $3::uuid)
+ORDER BY mutes.created_at DESC, mutes.muted_id DESC
+LIMIT $4
+`
+
+type ListMutesParams struct {
+	UserID          uuid.UUID
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+type ListMutesRow struct {
+	ID        uuid.UUID
+	Handle    sql.NullString
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListMutes(ctx context.Context, arg ListMutesParams) ([]ListMutesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMutes,
+		arg.UserID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMutesRow
+	for rows.Next() {
+		var i ListMutesRow
+		if err := rows.Scan(&i.ID, &i.Handle, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
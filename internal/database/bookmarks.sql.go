@@ -0,0 +1,155 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: bookmarks.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const deleteBookmark = `-- name: DeleteBookmark :exec
+DELETE FROM bookmarks WHERE user_id = $1 AND chirp_id = $2
+`
+
+type DeleteBookmarkParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) DeleteBookmark(ctx context.Context, arg DeleteBookmarkParams) error {
+	_, err := q.db.ExecContext(ctx, deleteBookmark, arg.UserID, arg.ChirpID)
+	return err
+}
+
+const getBookmarkedChirpIDs = `-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = $1
+AND chirp_id = ANY($2::uuid[])
+`
+
+type GetBookmarkedChirpIDsParams struct {
+	UserID   uuid.UUID
+	ChirpIds []uuid.UUID
+}
+
+func (q *Queries) GetBookmarkedChirpIDs(ctx context.Context, arg GetBookmarkedChirpIDsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarkedChirpIDs, arg.UserID, pq.Array(arg.ChirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var chirp_id uuid.UUID
+		if err := rows.Scan(&chirp_id); err != nil {
+			return nil, err
+		}
+		items = append(items, chirp_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBookmarks = `-- name: ListBookmarks :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search, chirps.in_reply_to, chirps.deleted_at, chirps.kind, chirps.reference_chirp_id, chirps.edited_at, chirps.created_xid, bookmarks.created_at AS bookmarked_at
+FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = $1
+AND ($2::uuid IS NULL OR bookmarks.collection_id = $2::uuid)
+AND chirps.deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $1 AND hidden_users.hidden_id = chirps.user_id
+)
+AND (bookmarks.created_at, bookmarks.chirp_id) < ($3::timestamp, $4::uuid)
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT $5
+`
+
+type ListBookmarksParams struct {
+	UserID          uuid.UUID
+	CollectionID    uuid.NullUUID
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+type ListBookmarksRow struct {
+	Chirp        Chirp
+	BookmarkedAt time.Time
+}
+
+// Bookmarks of deleted chirps, or of chirps by users hidden from the
+// owner, are left out rather than listed as dangling IDs.
+func (q *Queries) ListBookmarks(ctx context.Context, arg ListBookmarksParams) ([]ListBookmarksRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBookmarks,
+		arg.UserID,
+		arg.CollectionID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBookmarksRow
+	for rows.Next() {
+		var i ListBookmarksRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.Search,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.Kind,
+			&i.Chirp.ReferenceChirpID,
+			&i.Chirp.EditedAt,
+			&i.Chirp.CreatedXid,
+			&i.BookmarkedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertBookmark = `-- name: UpsertBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at, collection_id)
+VALUES ($1, $2, NOW(), $3)
+ON CONFLICT (user_id, chirp_id) DO UPDATE SET collection_id = EXCLUDED.collection_id
+`
+
+type UpsertBookmarkParams struct {
+	UserID       uuid.UUID
+	ChirpID      uuid.UUID
+	CollectionID uuid.NullUUID
+}
+
+// Bookmarking a chirp again only moves it to another collection; it keeps
+// its place in the list.
+func (q *Queries) UpsertBookmark(ctx context.Context, arg UpsertBookmarkParams) error {
+	_, err := q.db.ExecContext(ctx, upsertBookmark, arg.UserID, arg.ChirpID, arg.CollectionID)
+	return err
+}
@@ -0,0 +1,175 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: chirp_likes.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const countLikesByChirpIDs = `-- name: CountLikesByChirpIDs :many
+SELECT chirp_id, COUNT(*) AS likes
+FROM chirp_likes
+WHERE chirp_id = ANY($1::uuid[])
+GROUP BY chirp_id
+`
+
+type CountLikesByChirpIDsRow struct {
+	ChirpID uuid.UUID
+	Likes   int64
+}
+
+func (q *Queries) CountLikesByChirpIDs(ctx context.Context, chirpIds []uuid.UUID) ([]CountLikesByChirpIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countLikesByChirpIDs, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountLikesByChirpIDsRow
+	for rows.Next() {
+		var i CountLikesByChirpIDsRow
+		if err := rows.Scan(&i.ChirpID, &i.Likes); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createChirpLike = `-- name: CreateChirpLike :execrows
+INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING
+`
+
+type CreateChirpLikeParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) CreateChirpLike(ctx context.Context, arg CreateChirpLikeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createChirpLike, arg.UserID, arg.ChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteChirpLike = `-- name: DeleteChirpLike :exec
+DELETE FROM chirp_likes WHERE user_id = $1 AND chirp_id = $2
+`
+
+type DeleteChirpLikeParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) DeleteChirpLike(ctx context.Context, arg DeleteChirpLikeParams) error {
+	_, err := q.db.ExecContext(ctx, deleteChirpLike, arg.UserID, arg.ChirpID)
+	return err
+}
+
+const getLikedChirpIDs = `-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = $1
+AND chirp_id = ANY($2::uuid[])
+`
+
+type GetLikedChirpIDsParams struct {
+	UserID   uuid.UUID
+	ChirpIds []uuid.UUID
+}
+
+func (q *Queries) GetLikedChirpIDs(ctx context.Context, arg GetLikedChirpIDsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getLikedChirpIDs, arg.UserID, pq.Array(arg.ChirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var chirp_id uuid.UUID
+		if err := rows.Scan(&chirp_id); err != nil {
+			return nil, err
+		}
+		items = append(items, chirp_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpLikers = `-- name: ListChirpLikers :many
+SELECT users.id, users.handle, chirp_likes.created_at AS liked_at
+FROM chirp_likes
+JOIN users ON users.id = chirp_likes.user_id
+WHERE chirp_likes.chirp_id = $1
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $2 AND hidden_users.hidden_id = users.id
+)
+AND (chirp_likes.created_at, chirp_likes.user_id) < ($3::timestamp, $4::uuid)
+ORDER BY chirp_likes.created_at DESC, chirp_likes.user_id DESC
+LIMIT $5
+`
+
+type ListChirpLikersParams struct {
+	ChirpID         uuid.UUID
+	ViewerID        uuid.UUID
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+type ListChirpLikersRow struct {
+	ID      uuid.UUID
+	Handle  sql.NullString
+	LikedAt time.Time
+}
+
+// Leaves out users hidden from the viewer.
+func (q *Queries) ListChirpLikers(ctx context.Context, arg ListChirpLikersParams) ([]ListChirpLikersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpLikers,
+		arg.ChirpID,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChirpLikersRow
+	for rows.Next() {
+		var i ListChirpLikersRow
+		if err := rows.Scan(&i.ID, &i.Handle, &i.LikedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
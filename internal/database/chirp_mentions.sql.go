@@ -0,0 +1,129 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: chirp_mentions.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createChirpMention = `-- name: CreateChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING
+`
+
+type CreateChirpMentionParams struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+func (q *Queries) CreateChirpMention(ctx context.Context, arg CreateChirpMentionParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpMention, arg.ChirpID, arg.UserID)
+	return err
+}
+
+const deleteChirpMentions = `-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions WHERE chirp_id = $1
+`
+
+func (q *Queries) DeleteChirpMentions(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteChirpMentions, chirpID)
+	return err
+}
+
+const listChirpMentionUserIDs = `-- name: ListChirpMentionUserIDs :many
+SELECT user_id FROM chirp_mentions WHERE chirp_id = $1
+`
+
+func (q *Queries) ListChirpMentionUserIDs(ctx context.Context, chirpID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpMentionUserIDs, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var user_id uuid.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMentionsForUser = `-- name: ListMentionsForUser :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search, chirps.in_reply_to, chirps.deleted_at, chirps.kind, chirps.reference_chirp_id, chirps.edited_at, chirps.created_xid FROM chirps
+JOIN chirp_mentions ON chirp_mentions.chirp_id = chirps.id
+WHERE chirp_mentions.user_id = $1
+AND chirps.deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $2 AND hidden_users.hidden_id = chirps.user_id
+)
+AND (chirps.created_at, chirps.id) < ($3::timestamp, $4::uuid)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT $5
+`
+
+type ListMentionsForUserParams struct {
+	UserID          uuid.UUID
+	ViewerID        uuid.UUID
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+func (q *Queries) ListMentionsForUser(ctx context.Context, arg ListMentionsForUserParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listMentionsForUser,
+		arg.UserID,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Search,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.EditedAt,
+			&i.CreatedXid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,66 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: chirp_revisions.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createChirpRevision = `-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions (id, chirp_id, body, created_at)
+VALUES (
+    gen_random_uuid (),
+    $1,
+    $2,
+    NOW()
+)
+`
+
+type CreateChirpRevisionParams struct {
+	ChirpID uuid.UUID
+	Body    string
+}
+
+func (q *Queries) CreateChirpRevision(ctx context.Context, arg CreateChirpRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpRevision, arg.ChirpID, arg.Body)
+	return err
+}
+
+const listChirpRevisions = `-- name: ListChirpRevisions :many
+SELECT id, chirp_id, body, created_at FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY created_at DESC, id DESC
+`
+
+func (q *Queries) ListChirpRevisions(ctx context.Context, chirpID uuid.UUID) ([]ChirpRevision, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpRevisions, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpRevision
+	for rows.Next() {
+		var i ChirpRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.Body,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,29 +7,79 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countRepliesByChirpIDs = `-- name: CountRepliesByChirpIDs :many
+SELECT in_reply_to::uuid AS chirp_id, COUNT(*) AS replies
+FROM chirps
+WHERE in_reply_to = ANY($1::uuid[])
+AND deleted_at IS NULL
+GROUP BY in_reply_to
+`
+
+type CountRepliesByChirpIDsRow struct {
+	ChirpID uuid.UUID
+	Replies int64
+}
+
+func (q *Queries) CountRepliesByChirpIDs(ctx context.Context, chirpIds []uuid.UUID) ([]CountRepliesByChirpIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countRepliesByChirpIDs, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRepliesByChirpIDsRow
+	for rows.Next() {
+		var i CountRepliesByChirpIDsRow
+		if err := rows.Scan(&i.ChirpID, &i.Replies); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, kind, reference_chirp_id)
 VALUES (
     gen_random_uuid (),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, search, in_reply_to, deleted_at, kind, reference_chirp_id, edited_at, created_xid
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body             string
+	UserID           uuid.UUID
+	InReplyTo        uuid.NullUUID
+	Kind             string
+	ReferenceChirpID uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.Kind,
+		arg.ReferenceChirpID,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +87,13 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Search,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.Kind,
+		&i.ReferenceChirpID,
+		&i.EditedAt,
+		&i.CreatedXid,
 	)
 	return i, err
 }
@@ -50,12 +107,29 @@ func (q *Queries) DeleteChirpByID(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.id, parent.in_reply_to, 1 AS depth
+    FROM chirps parent
+    WHERE parent.id = (SELECT start.in_reply_to FROM chirps start WHERE start.id = $1)
+    UNION ALL
+    SELECT chirps.id, chirps.in_reply_to, ancestors.depth + 1
+    FROM chirps
+    JOIN ancestors ON chirps.id = ancestors.in_reply_to
+    WHERE ancestors.depth < $2::int
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search, chirps.in_reply_to, chirps.deleted_at, chirps.kind, chirps.reference_chirp_id, chirps.edited_at, chirps.created_xid FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+ORDER BY ancestors.depth DESC
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+type GetChirpAncestorsParams struct {
+	ChirpID  uuid.UUID
+	MaxDepth int32
+}
+
+func (q *Queries) GetChirpAncestors(ctx context.Context, arg GetChirpAncestorsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, arg.ChirpID, arg.MaxDepth)
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +143,13 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.Search,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.EditedAt,
+			&i.CreatedXid,
 		); err != nil {
 			return nil, err
 		}
@@ -84,7 +165,7 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 }
 
 const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1
+SELECT id, created_at, updated_at, body, user_id, search, in_reply_to, deleted_at, kind, reference_chirp_id, edited_at, created_xid FROM chirps WHERE id = $1
 `
 
 func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -96,6 +177,659 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.Search,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.Kind,
+		&i.ReferenceChirpID,
+		&i.EditedAt,
+		&i.CreatedXid,
+	)
+	return i, err
+}
+
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT reply.id, 1 AS depth
+    FROM chirps reply
+    WHERE reply.in_reply_to = $2::uuid
+    UNION ALL
+    SELECT chirps.id, descendants.depth + 1
+    FROM chirps
+    JOIN descendants ON chirps.in_reply_to = descendants.id
+    WHERE descendants.depth < $3::int
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search, chirps.in_reply_to, chirps.deleted_at, chirps.kind, chirps.reference_chirp_id, chirps.edited_at, chirps.created_xid FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+ORDER BY chirps.created_at, chirps.id
+LIMIT $1
+`
+
+type GetChirpDescendantsParams struct {
+	MaxRows  int32
+	ChirpID  uuid.UUID
+	MaxDepth int32
+}
+
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants, arg.MaxRows, arg.ChirpID, arg.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Search,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.EditedAt,
+			&i.CreatedXid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, search, in_reply_to, deleted_at, kind, reference_chirp_id, edited_at, created_xid FROM chirps WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Search,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.EditedAt,
+			&i.CreatedXid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCurrentSnapshot = `-- name: GetCurrentSnapshot :one
+SELECT pg_current_snapshot()::text
+`
+
+func (q *Queries) GetCurrentSnapshot(ctx context.Context) (string, error) {
+	row := q.db.QueryRowContext(ctx, getCurrentSnapshot)
+	var column_1 string
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const listChirps = `-- name: ListChirps :many
+SELECT id, created_at, updated_at, body, user_id, search, in_reply_to, deleted_at, kind, reference_chirp_id, edited_at, created_xid FROM chirps
+WHERE deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $1 AND hidden_users.hidden_id = chirps.user_id
+)
+AND (created_at, id) > ($2::timestamp, $3::uuid)
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListChirpsParams struct {
+	ViewerID       uuid.UUID
+	AfterCreatedAt time.Time
+	AfterID        uuid.UUID
+	MaxRows        int32
+}
+
+func (q *Queries) ListChirps(ctx context.Context, arg ListChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirps,
+		arg.ViewerID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Search,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.EditedAt,
+			&i.CreatedXid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsByAuthor = `-- name: ListChirpsByAuthor :many
+SELECT id, created_at, updated_at, body, user_id, search, in_reply_to, deleted_at, kind, reference_chirp_id, edited_at, created_xid FROM chirps
+WHERE user_id = $1
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $2 AND hidden_users.hidden_id = chirps.user_id
+)
+AND (created_at, id) > ($3::timestamp, $4::uuid)
+ORDER BY created_at, id
+LIMIT $5
+`
+
+type ListChirpsByAuthorParams struct {
+	UserID         uuid.UUID
+	ViewerID       uuid.UUID
+	AfterCreatedAt time.Time
+	AfterID        uuid.UUID
+	MaxRows        int32
+}
+
+func (q *Queries) ListChirpsByAuthor(ctx context.Context, arg ListChirpsByAuthorParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsByAuthor,
+		arg.UserID,
+		arg.ViewerID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Search,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.EditedAt,
+			&i.CreatedXid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsByAuthorDesc = `-- name: ListChirpsByAuthorDesc :many
+SELECT id, created_at, updated_at, body, user_id, search, in_reply_to, deleted_at, kind, reference_chirp_id, edited_at, created_xid FROM chirps
+WHERE user_id = $1
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $2 AND hidden_users.hidden_id = chirps.user_id
+)
+AND (created_at, id) < ($3::timestamp, $4::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type ListChirpsByAuthorDescParams struct {
+	UserID          uuid.UUID
+	ViewerID        uuid.UUID
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+func (q *Queries) ListChirpsByAuthorDesc(ctx context.Context, arg ListChirpsByAuthorDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsByAuthorDesc,
+		arg.UserID,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Search,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.EditedAt,
+			&i.CreatedXid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsCommittedSince = `-- name: ListChirpsCommittedSince :many
+SELECT id, created_at, updated_at, body, user_id, search, in_reply_to, deleted_at, kind, reference_chirp_id, edited_at, created_xid FROM chirps
+WHERE created_xid >= pg_snapshot_xmin($1::text::pg_snapshot)
+AND NOT pg_visible_in_snapshot(created_xid, $1::text::pg_snapshot)
+AND id <> $2::uuid
+AND deleted_at IS NULL
+AND ($3::uuid IS NULL OR user_id = $3::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $4 AND hidden_users.hidden_id = chirps.user_id
+)
+ORDER BY created_at, id
+LIMIT $5
+`
+
+type ListChirpsCommittedSinceParams struct {
+	Snapshot string
+	ExceptID uuid.UUID
+	AuthorID uuid.NullUUID
+	ViewerID uuid.UUID
+	MaxRows  int32
+}
+
+// Chirps whose transaction had not committed when snapshot was taken,
+// oldest first, leaving out except_id.
+func (q *Queries) ListChirpsCommittedSince(ctx context.Context, arg ListChirpsCommittedSinceParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsCommittedSince,
+		arg.Snapshot,
+		arg.ExceptID,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Search,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.EditedAt,
+			&i.CreatedXid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, search, in_reply_to, deleted_at, kind, reference_chirp_id, edited_at, created_xid FROM chirps
+WHERE deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $1 AND hidden_users.hidden_id = chirps.user_id
+)
+AND (created_at, id) < ($2::timestamp, $3::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type ListChirpsDescParams struct {
+	ViewerID        uuid.UUID
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Search,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.EditedAt,
+			&i.CreatedXid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockChirp = `-- name: LockChirp :one
+SELECT id, created_at, updated_at, body, user_id, search, in_reply_to, deleted_at, kind, reference_chirp_id, edited_at, created_xid FROM chirps WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) LockChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, lockChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Search,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.Kind,
+		&i.ReferenceChirpID,
+		&i.EditedAt,
+		&i.CreatedXid,
+	)
+	return i, err
+}
+
+const notifyChirpEvent = `-- name: NotifyChirpEvent :exec
+SELECT pg_notify('chirp_events', json_build_object(
+    'type', $1::text,
+    'chirp_id', $2::uuid,
+    'user_id', $3::uuid,
+    'snapshot', pg_current_snapshot()::text
+)::text)
+`
+
+type NotifyChirpEventParams struct {
+	Type    string
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+// Inside a transaction the notification is only sent on commit. The
+// snapshot lists the transactions that had committed by then, all of
+// whose notifications are delivered before this one.
+func (q *Queries) NotifyChirpEvent(ctx context.Context, arg NotifyChirpEventParams) error {
+	_, err := q.db.ExecContext(ctx, notifyChirpEvent, arg.Type, arg.ChirpID, arg.UserID)
+	return err
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < $1::timestamp
+AND NOT EXISTS (SELECT 1 FROM chirps AS reply WHERE reply.in_reply_to = chirps.id)
+AND NOT EXISTS (SELECT 1 FROM chirps AS ref WHERE ref.reference_chirp_id = chirps.id)
+`
+
+// Tombstones that replies or rechirps still point at are kept, so threads
+// hold together. They go once everything pointing at them has been purged.
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL,
+updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, created_at, updated_at, body, user_id, search, in_reply_to, deleted_at, kind, reference_chirp_id, edited_at, created_xid
+`
+
+func (q *Queries) RestoreChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Search,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.Kind,
+		&i.ReferenceChirpID,
+		&i.EditedAt,
+		&i.CreatedXid,
+	)
+	return i, err
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search, chirps.in_reply_to, chirps.deleted_at, chirps.kind, chirps.reference_chirp_id, chirps.edited_at, chirps.created_xid,
+    ranked.rank::real AS rank,
+    ts_headline('english',
+        replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        websearch_to_tsquery('english', $1),
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')::text AS snippet
+FROM (
+    SELECT id, ts_rank(search, websearch_to_tsquery('english', $1)) AS rank
+    FROM chirps
+    WHERE search @@ websearch_to_tsquery('english', $1)
+    AND deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = $2 AND hidden_users.hidden_id = chirps.user_id
+    )
+) ranked
+JOIN chirps ON chirps.id = ranked.id
+WHERE (ranked.rank, ranked.id) < ($3::real, $4::uuid)
+ORDER BY ranked.rank DESC, ranked.id DESC
+LIMIT $5
+`
+
+type SearchChirpsParams struct {
+	Query      string
+	ViewerID   uuid.UUID
+	BeforeRank float32
+	BeforeID   uuid.UUID
+	MaxRows    int32
+}
+
+type SearchChirpsRow struct {
+	Chirp   Chirp
+	Rank    float32
+	Snippet string
+}
+
+// The snippet is HTML element content: &, < and > in the body are escaped
+// before the matches are wrapped in <mark> tags. The text search parser
+// reads the entities as entities rather than words, so escaping does not
+// change what is highlighted.
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.ViewerID,
+		arg.BeforeRank,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.Search,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.Kind,
+			&i.Chirp.ReferenceChirpID,
+			&i.Chirp.EditedAt,
+			&i.Chirp.CreatedXid,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET deleted_at = NOW(),
+updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteChirp, id)
+	return err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2,
+updated_at = NOW(),
+edited_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, search, in_reply_to, deleted_at, kind, reference_chirp_id, edited_at, created_xid
+`
+
+type UpdateChirpBodyParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.Search,
+		&i.InReplyTo,
+		&i.DeletedAt,
+		&i.Kind,
+		&i.ReferenceChirpID,
+		&i.EditedAt,
+		&i.CreatedXid,
 	)
 	return i, err
 }
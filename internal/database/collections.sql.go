@@ -0,0 +1,214 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: collections.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const countCollectionsByUser = `-- name: CountCollectionsByUser :one
+SELECT COUNT(*) FROM collections WHERE user_id = $1
+`
+
+func (q *Queries) CountCollectionsByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countCollectionsByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createCollection = `-- name: CreateCollection :one
+INSERT INTO collections (id, user_id, created_at, updated_at, name, position)
+VALUES (
+    gen_random_uuid (),
+    $1,
+    NOW(),
+    NOW(),
+    $2,
+    (SELECT COALESCE(MAX(position) + 1, 0) FROM collections WHERE user_id = $1)
+)
+RETURNING id, user_id, created_at, updated_at, name, position
+`
+
+type CreateCollectionParams struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+func (q *Queries) CreateCollection(ctx context.Context, arg CreateCollectionParams) (Collection, error) {
+	row := q.db.QueryRowContext(ctx, createCollection, arg.UserID, arg.Name)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Position,
+	)
+	return i, err
+}
+
+const deleteCollection = `-- name: DeleteCollection :execrows
+DELETE FROM collections WHERE id = $1 AND user_id = $2
+`
+
+type DeleteCollectionParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteCollection(ctx context.Context, arg DeleteCollectionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCollection, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getCollectionByID = `-- name: GetCollectionByID :one
+SELECT id, user_id, created_at, updated_at, name, position FROM collections WHERE id = $1 AND user_id = $2
+`
+
+type GetCollectionByIDParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetCollectionByID(ctx context.Context, arg GetCollectionByIDParams) (Collection, error) {
+	row := q.db.QueryRowContext(ctx, getCollectionByID, arg.ID, arg.UserID)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Position,
+	)
+	return i, err
+}
+
+const listCollectionsByUser = `-- name: ListCollectionsByUser :many
+SELECT id, user_id, created_at, updated_at, name, position FROM collections
+WHERE user_id = $1
+ORDER BY position, created_at, id
+`
+
+func (q *Queries) ListCollectionsByUser(ctx context.Context, userID uuid.UUID) ([]Collection, error) {
+	rows, err := q.db.QueryContext(ctx, listCollectionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Collection
+	for rows.Next() {
+		var i Collection
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockCollectionsByUser = `-- name: LockCollectionsByUser :many
+SELECT id, user_id, created_at, updated_at, name, position FROM collections
+WHERE user_id = $1
+ORDER BY position, created_at, id
+FOR UPDATE
+`
+
+func (q *Queries) LockCollectionsByUser(ctx context.Context, userID uuid.UUID) ([]Collection, error) {
+	rows, err := q.db.QueryContext(ctx, lockCollectionsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Collection
+	for rows.Next() {
+		var i Collection
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const renameCollection = `-- name: RenameCollection :one
+UPDATE collections
+SET name = $3,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING id, user_id, created_at, updated_at, name, position
+`
+
+type RenameCollectionParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Name   string
+}
+
+func (q *Queries) RenameCollection(ctx context.Context, arg RenameCollectionParams) (Collection, error) {
+	row := q.db.QueryRowContext(ctx, renameCollection, arg.ID, arg.UserID, arg.Name)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Position,
+	)
+	return i, err
+}
+
+const setCollectionPosition = `-- name: SetCollectionPosition :exec
+UPDATE collections
+SET position = $3,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+`
+
+type SetCollectionPositionParams struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	Position int32
+}
+
+func (q *Queries) SetCollectionPosition(ctx context.Context, arg SetCollectionPositionParams) error {
+	_, err := q.db.ExecContext(ctx, setCollectionPosition, arg.ID, arg.UserID, arg.Position)
+	return err
+}
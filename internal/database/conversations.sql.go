@@ -0,0 +1,257 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: conversations.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const advanceConversation = `-- name: AdvanceConversation :one
+UPDATE conversations
+SET updated_at = NOW(),
+last_seq = last_seq + 1
+WHERE id = $1
+RETURNING last_seq
+`
+
+// Returns the seq of a new message. The row lock it takes is held until
+// the message commits, so messages commit in seq order.
+func (q *Queries) AdvanceConversation(ctx context.Context, id uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, advanceConversation, id)
+	var last_seq int64
+	err := row.Scan(&last_seq)
+	return last_seq, err
+}
+
+const createConversation = `-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, name, direct_key)
+VALUES (
+    gen_random_uuid (),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING id, created_at, updated_at, name, direct_key, last_seq
+`
+
+type CreateConversationParams struct {
+	Name      string
+	DirectKey sql.NullString
+}
+
+func (q *Queries) CreateConversation(ctx context.Context, arg CreateConversationParams) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, createConversation, arg.Name, arg.DirectKey)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.DirectKey,
+		&i.LastSeq,
+	)
+	return i, err
+}
+
+const createConversationMember = `-- name: CreateConversationMember :exec
+INSERT INTO conversation_members (conversation_id, user_id, joined_at, last_read_seq)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    (SELECT last_seq FROM conversations WHERE id = $1)
+)
+`
+
+type CreateConversationMemberParams struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+}
+
+// Messages sent before the member joined count as read.
+func (q *Queries) CreateConversationMember(ctx context.Context, arg CreateConversationMemberParams) error {
+	_, err := q.db.ExecContext(ctx, createConversationMember, arg.ConversationID, arg.UserID)
+	return err
+}
+
+const getConversationByDirectKey = `-- name: GetConversationByDirectKey :one
+SELECT id, created_at, updated_at, name, direct_key, last_seq FROM conversations WHERE direct_key = $1
+`
+
+func (q *Queries) GetConversationByDirectKey(ctx context.Context, directKey sql.NullString) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, getConversationByDirectKey, directKey)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.DirectKey,
+		&i.LastSeq,
+	)
+	return i, err
+}
+
+const getConversationForMember = `-- name: GetConversationForMember :one
+SELECT conversations.id, conversations.created_at, conversations.updated_at, conversations.name, conversations.direct_key, conversations.last_seq FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversations.id = $1 AND conversation_members.user_id = $2
+`
+
+type GetConversationForMemberParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetConversationForMember(ctx context.Context, arg GetConversationForMemberParams) (Conversation, error) {
+	row := q.db.QueryRowContext(ctx, getConversationForMember, arg.ID, arg.UserID)
+	var i Conversation
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.DirectKey,
+		&i.LastSeq,
+	)
+	return i, err
+}
+
+const listConversationMembers = `-- name: ListConversationMembers :many
+SELECT conversation_members.conversation_id, users.id, users.handle
+FROM conversation_members
+JOIN users ON users.id = conversation_members.user_id
+WHERE conversation_members.conversation_id = ANY($1::uuid[])
+ORDER BY conversation_members.joined_at, users.id
+`
+
+type ListConversationMembersRow struct {
+	ConversationID uuid.UUID
+	ID             uuid.UUID
+	Handle         sql.NullString
+}
+
+func (q *Queries) ListConversationMembers(ctx context.Context, conversationIds []uuid.UUID) ([]ListConversationMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listConversationMembers, pq.Array(conversationIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListConversationMembersRow
+	for rows.Next() {
+		var i ListConversationMembersRow
+		if err := rows.Scan(&i.ConversationID, &i.ID, &i.Handle); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listConversationsForUser = `-- name: ListConversationsForUser :many
+SELECT
+    conversations.id, conversations.created_at, conversations.updated_at, conversations.name, conversations.direct_key, conversations.last_seq,
+    (
+        SELECT COUNT(*) FROM messages
+        WHERE messages.conversation_id = conversations.id
+        AND messages.seq > conversation_members.last_read_seq
+        AND messages.sender_id <> $1
+        AND NOT EXISTS (
+            SELECT 1 FROM blocks
+            WHERE (blocks.blocker_id = $1 AND blocks.blocked_id = messages.sender_id)
+            OR (blocks.blocker_id = messages.sender_id AND blocks.blocked_id = $1)
+        )
+    ) AS unread_count
+FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversation_members.user_id = $1
+AND (conversations.updated_at, conversations.id) < ($2::timestamp, $3::uuid)
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT $4
+`
+
+type ListConversationsForUserParams struct {
+	UserID          uuid.UUID
+	BeforeUpdatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+type ListConversationsForUserRow struct {
+	Conversation Conversation
+	UnreadCount  int64
+}
+
+// Most recently active first. Messages from users blocked either way are
+// not counted as unread, since they are not listed either.
+func (q *Queries) ListConversationsForUser(ctx context.Context, arg ListConversationsForUserParams) ([]ListConversationsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listConversationsForUser,
+		arg.UserID,
+		arg.BeforeUpdatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListConversationsForUserRow
+	for rows.Next() {
+		var i ListConversationsForUserRow
+		if err := rows.Scan(
+			&i.Conversation.ID,
+			&i.Conversation.CreatedAt,
+			&i.Conversation.UpdatedAt,
+			&i.Conversation.Name,
+			&i.Conversation.DirectKey,
+			&i.Conversation.LastSeq,
+			&i.UnreadCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markConversationRead = `-- name: MarkConversationRead :exec
+UPDATE conversation_members
+SET last_read_seq = GREATEST(
+    last_read_seq,
+    (SELECT last_seq FROM conversations WHERE conversations.id = $1)
+)
+WHERE conversation_id = $1 AND user_id = $2
+`
+
+type MarkConversationReadParams struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+}
+
+// Messages still being sent have a seq above the committed last_seq, so
+// they stay unread.
+func (q *Queries) MarkConversationRead(ctx context.Context, arg MarkConversationReadParams) error {
+	_, err := q.db.ExecContext(ctx, markConversationRead, arg.ConversationID, arg.UserID)
+	return err
+}
@@ -0,0 +1,288 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: drafts.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const claimDueDraft = `-- name: ClaimDueDraft :one
+SELECT id, created_at, updated_at, user_id, body, in_reply_to, kind, reference_chirp_id, publish_at, media_ids, retry_at FROM drafts
+WHERE publish_at <= NOW()
+AND (retry_at IS NULL OR retry_at <= NOW())
+ORDER BY publish_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED
+`
+
+// Locks the oldest due draft so that only one instance publishes it.
+// Rows already locked by another instance are skipped, not waited for,
+// as are drafts waiting to be retried.
+func (q *Queries) ClaimDueDraft(ctx context.Context) (Draft, error) {
+	row := q.db.QueryRowContext(ctx, claimDueDraft)
+	var i Draft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.ReferenceChirpID,
+		&i.PublishAt,
+		pq.Array(&i.MediaIds),
+		&i.RetryAt,
+	)
+	return i, err
+}
+
+const createDraft = `-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, in_reply_to, kind, reference_chirp_id, publish_at, media_ids)
+VALUES (
+    gen_random_uuid (),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING id, created_at, updated_at, user_id, body, in_reply_to, kind, reference_chirp_id, publish_at, media_ids, retry_at
+`
+
+type CreateDraftParams struct {
+	UserID           uuid.UUID
+	Body             string
+	InReplyTo        uuid.NullUUID
+	Kind             string
+	ReferenceChirpID uuid.NullUUID
+	PublishAt        sql.NullTime
+	MediaIds         []uuid.UUID
+}
+
+func (q *Queries) CreateDraft(ctx context.Context, arg CreateDraftParams) (Draft, error) {
+	row := q.db.QueryRowContext(ctx, createDraft,
+		arg.UserID,
+		arg.Body,
+		arg.InReplyTo,
+		arg.Kind,
+		arg.ReferenceChirpID,
+		arg.PublishAt,
+		pq.Array(arg.MediaIds),
+	)
+	var i Draft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.ReferenceChirpID,
+		&i.PublishAt,
+		pq.Array(&i.MediaIds),
+		&i.RetryAt,
+	)
+	return i, err
+}
+
+const deferDraft = `-- name: DeferDraft :exec
+UPDATE drafts SET retry_at = $2 WHERE id = $1
+`
+
+type DeferDraftParams struct {
+	ID      uuid.UUID
+	RetryAt sql.NullTime
+}
+
+func (q *Queries) DeferDraft(ctx context.Context, arg DeferDraftParams) error {
+	_, err := q.db.ExecContext(ctx, deferDraft, arg.ID, arg.RetryAt)
+	return err
+}
+
+const deleteDraft = `-- name: DeleteDraft :exec
+DELETE FROM drafts WHERE id = $1
+`
+
+func (q *Queries) DeleteDraft(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteDraft, id)
+	return err
+}
+
+const getDraftByID = `-- name: GetDraftByID :one
+SELECT id, created_at, updated_at, user_id, body, in_reply_to, kind, reference_chirp_id, publish_at, media_ids, retry_at FROM drafts WHERE id = $1
+`
+
+func (q *Queries) GetDraftByID(ctx context.Context, id uuid.UUID) (Draft, error) {
+	row := q.db.QueryRowContext(ctx, getDraftByID, id)
+	var i Draft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.ReferenceChirpID,
+		&i.PublishAt,
+		pq.Array(&i.MediaIds),
+		&i.RetryAt,
+	)
+	return i, err
+}
+
+const listDraftsByUser = `-- name: ListDraftsByUser :many
+SELECT id, created_at, updated_at, user_id, body, in_reply_to, kind, reference_chirp_id, publish_at, media_ids, retry_at FROM drafts
+WHERE user_id = $1
+AND (created_at, id) < ($2::timestamp, $3::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type ListDraftsByUserParams struct {
+	UserID          uuid.UUID
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+func (q *Queries) ListDraftsByUser(ctx context.Context, arg ListDraftsByUserParams) ([]Draft, error) {
+	rows, err := q.db.QueryContext(ctx, listDraftsByUser,
+		arg.UserID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Draft
+	for rows.Next() {
+		var i Draft
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.InReplyTo,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.PublishAt,
+			pq.Array(&i.MediaIds),
+			&i.RetryAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockDraft = `-- name: LockDraft :one
+SELECT id, created_at, updated_at, user_id, body, in_reply_to, kind, reference_chirp_id, publish_at, media_ids, retry_at FROM drafts WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) LockDraft(ctx context.Context, id uuid.UUID) (Draft, error) {
+	row := q.db.QueryRowContext(ctx, lockDraft, id)
+	var i Draft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.ReferenceChirpID,
+		&i.PublishAt,
+		pq.Array(&i.MediaIds),
+		&i.RetryAt,
+	)
+	return i, err
+}
+
+const unscheduleDraft = `-- name: UnscheduleDraft :exec
+UPDATE drafts
+SET publish_at = NULL,
+retry_at = NULL,
+updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) UnscheduleDraft(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, unscheduleDraft, id)
+	return err
+}
+
+const updateDraft = `-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $2,
+in_reply_to = $3,
+kind = $4,
+reference_chirp_id = $5,
+publish_at = $6,
+media_ids = $7,
+retry_at = NULL,
+updated_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, user_id, body, in_reply_to, kind, reference_chirp_id, publish_at, media_ids, retry_at
+`
+
+type UpdateDraftParams struct {
+	ID               uuid.UUID
+	Body             string
+	InReplyTo        uuid.NullUUID
+	Kind             string
+	ReferenceChirpID uuid.NullUUID
+	PublishAt        sql.NullTime
+	MediaIds         []uuid.UUID
+}
+
+func (q *Queries) UpdateDraft(ctx context.Context, arg UpdateDraftParams) (Draft, error) {
+	row := q.db.QueryRowContext(ctx, updateDraft,
+		arg.ID,
+		arg.Body,
+		arg.InReplyTo,
+		arg.Kind,
+		arg.ReferenceChirpID,
+		arg.PublishAt,
+		pq.Array(arg.MediaIds),
+	)
+	var i Draft
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.InReplyTo,
+		&i.Kind,
+		&i.ReferenceChirpID,
+		&i.PublishAt,
+		pq.Array(&i.MediaIds),
+		&i.RetryAt,
+	)
+	return i, err
+}
@@ -0,0 +1,168 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: hashtags.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createChirpHashtag = `-- name: CreateChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING
+`
+
+type CreateChirpHashtagParams struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+}
+
+func (q *Queries) CreateChirpHashtag(ctx context.Context, arg CreateChirpHashtagParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpHashtag, arg.ChirpID, arg.HashtagID)
+	return err
+}
+
+const deleteChirpHashtags = `-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags WHERE chirp_id = $1
+`
+
+func (q *Queries) DeleteChirpHashtags(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteChirpHashtags, chirpID)
+	return err
+}
+
+const getTrendingHashtags = `-- name: GetTrendingHashtags :many
+SELECT hashtags.tag, COUNT(*) AS uses
+FROM chirp_hashtags
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.created_at > $1
+AND chirps.deleted_at IS NULL
+GROUP BY hashtags.tag
+ORDER BY uses DESC, hashtags.tag
+LIMIT $2
+`
+
+type GetTrendingHashtagsParams struct {
+	Since   time.Time
+	MaxRows int32
+}
+
+type GetTrendingHashtagsRow struct {
+	Tag  string
+	Uses int64
+}
+
+// Deleted chirps lose their tag links, but the join also keeps out any
+// linked before that was the case.
+func (q *Queries) GetTrendingHashtags(ctx context.Context, arg GetTrendingHashtagsParams) ([]GetTrendingHashtagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrendingHashtags, arg.Since, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrendingHashtagsRow
+	for rows.Next() {
+		var i GetTrendingHashtagsRow
+		if err := rows.Scan(&i.Tag, &i.Uses); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsByHashtag = `-- name: ListChirpsByHashtag :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search, chirps.in_reply_to, chirps.deleted_at, chirps.kind, chirps.reference_chirp_id, chirps.edited_at, chirps.created_xid FROM chirps
+JOIN chirp_hashtags ON chirp_hashtags.chirp_id = chirps.id
+JOIN hashtags ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = $1
+AND chirps.deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $2 AND hidden_users.hidden_id = chirps.user_id
+)
+AND (chirps.created_at, chirps.id) < ($3::timestamp, $4::uuid)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT $5
+`
+
+type ListChirpsByHashtagParams struct {
+	Tag             string
+	ViewerID        uuid.UUID
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+func (q *Queries) ListChirpsByHashtag(ctx context.Context, arg ListChirpsByHashtagParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsByHashtag,
+		arg.Tag,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Search,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.EditedAt,
+			&i.CreatedXid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertHashtag = `-- name: UpsertHashtag :one
+INSERT INTO hashtags (id, created_at, tag)
+VALUES (
+    gen_random_uuid (),
+    NOW(),
+    $1
+)
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING id, created_at, tag
+`
+
+func (q *Queries) UpsertHashtag(ctx context.Context, tag string) (Hashtag, error) {
+	row := q.db.QueryRowContext(ctx, upsertHashtag, tag)
+	var i Hashtag
+	err := row.Scan(&i.ID, &i.CreatedAt, &i.Tag)
+	return i, err
+}
@@ -0,0 +1,350 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: media.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const claimMedia = `-- name: ClaimMedia :one
+UPDATE media
+SET claimed_at = NOW()
+WHERE id = $1
+AND processed_at IS NULL
+AND (claimed_at IS NULL OR claimed_at < $2::timestamp)
+RETURNING id, created_at, user_id, blob_key, content_type, width, height, size_bytes, claimed_at, processed_at
+`
+
+type ClaimMediaParams struct {
+	ID          uuid.UUID
+	StaleBefore time.Time
+}
+
+// Takes media for processing unless it is done or another worker claimed
+// it after stale_before. A worker that dies mid-way loses its claim once
+// it goes stale.
+func (q *Queries) ClaimMedia(ctx context.Context, arg ClaimMediaParams) (Media, error) {
+	row := q.db.QueryRowContext(ctx, claimMedia, arg.ID, arg.StaleBefore)
+	var i Media
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.BlobKey,
+		&i.ContentType,
+		&i.Width,
+		&i.Height,
+		&i.SizeBytes,
+		&i.ClaimedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const createChirpMedia = `-- name: CreateChirpMedia :exec
+INSERT INTO chirp_media (chirp_id, media_id, position)
+VALUES ($1, $2, $3)
+`
+
+type CreateChirpMediaParams struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int16
+}
+
+func (q *Queries) CreateChirpMedia(ctx context.Context, arg CreateChirpMediaParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpMedia, arg.ChirpID, arg.MediaID, arg.Position)
+	return err
+}
+
+const createMedia = `-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, blob_key, content_type, width, height, size_bytes)
+VALUES (
+    gen_random_uuid (),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING id, created_at, user_id, blob_key, content_type, width, height, size_bytes, claimed_at, processed_at
+`
+
+type CreateMediaParams struct {
+	UserID      uuid.UUID
+	BlobKey     string
+	ContentType string
+	Width       int32
+	Height      int32
+	SizeBytes   int64
+}
+
+func (q *Queries) CreateMedia(ctx context.Context, arg CreateMediaParams) (Media, error) {
+	row := q.db.QueryRowContext(ctx, createMedia,
+		arg.UserID,
+		arg.BlobKey,
+		arg.ContentType,
+		arg.Width,
+		arg.Height,
+		arg.SizeBytes,
+	)
+	var i Media
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.BlobKey,
+		&i.ContentType,
+		&i.Width,
+		&i.Height,
+		&i.SizeBytes,
+		&i.ClaimedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const getMediaByID = `-- name: GetMediaByID :one
+SELECT id, created_at, user_id, blob_key, content_type, width, height, size_bytes, claimed_at, processed_at FROM media WHERE id = $1
+`
+
+func (q *Queries) GetMediaByID(ctx context.Context, id uuid.UUID) (Media, error) {
+	row := q.db.QueryRowContext(ctx, getMediaByID, id)
+	var i Media
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UserID,
+		&i.BlobKey,
+		&i.ContentType,
+		&i.Width,
+		&i.Height,
+		&i.SizeBytes,
+		&i.ClaimedAt,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const getMediaByIDs = `-- name: GetMediaByIDs :many
+SELECT id, created_at, user_id, blob_key, content_type, width, height, size_bytes, claimed_at, processed_at FROM media WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) GetMediaByIDs(ctx context.Context, ids []uuid.UUID) ([]Media, error) {
+	rows, err := q.db.QueryContext(ctx, getMediaByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Media
+	for rows.Next() {
+		var i Media
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UserID,
+			&i.BlobKey,
+			&i.ContentType,
+			&i.Width,
+			&i.Height,
+			&i.SizeBytes,
+			&i.ClaimedAt,
+			&i.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpMediaByChirpIDs = `-- name: ListChirpMediaByChirpIDs :many
+SELECT chirp_media.chirp_id, media.id, media.created_at, media.user_id, media.blob_key, media.content_type, media.width, media.height, media.size_bytes, media.claimed_at, media.processed_at
+FROM chirp_media
+JOIN media ON media.id = chirp_media.media_id
+WHERE chirp_media.chirp_id = ANY($1::uuid[])
+ORDER BY chirp_media.chirp_id, chirp_media.position
+`
+
+type ListChirpMediaByChirpIDsRow struct {
+	ChirpID uuid.UUID
+	Media   Media
+}
+
+func (q *Queries) ListChirpMediaByChirpIDs(ctx context.Context, chirpIds []uuid.UUID) ([]ListChirpMediaByChirpIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpMediaByChirpIDs, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChirpMediaByChirpIDsRow
+	for rows.Next() {
+		var i ListChirpMediaByChirpIDsRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.Media.ID,
+			&i.Media.CreatedAt,
+			&i.Media.UserID,
+			&i.Media.BlobKey,
+			&i.Media.ContentType,
+			&i.Media.Width,
+			&i.Media.Height,
+			&i.Media.SizeBytes,
+			&i.Media.ClaimedAt,
+			&i.Media.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMediaVariantsByMediaIDs = `-- name: ListMediaVariantsByMediaIDs :many
+SELECT media_id, name, created_at, blob_key, content_type, width, height, blurhash FROM media_variants
+WHERE media_id = ANY($1::uuid[])
+ORDER BY media_id, width
+`
+
+func (q *Queries) ListMediaVariantsByMediaIDs(ctx context.Context, mediaIds []uuid.UUID) ([]MediaVariant, error) {
+	rows, err := q.db.QueryContext(ctx, listMediaVariantsByMediaIDs, pq.Array(mediaIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MediaVariant
+	for rows.Next() {
+		var i MediaVariant
+		if err := rows.Scan(
+			&i.MediaID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.BlobKey,
+			&i.ContentType,
+			&i.Width,
+			&i.Height,
+			&i.Blurhash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnprocessedMediaIDs = `-- name: ListUnprocessedMediaIDs :many
+SELECT id FROM media
+WHERE processed_at IS NULL
+AND created_at < $1::timestamp
+AND (claimed_at IS NULL OR claimed_at < $2::timestamp)
+ORDER BY created_at
+LIMIT $3
+`
+
+type ListUnprocessedMediaIDsParams struct {
+	CreatedBefore time.Time
+	StaleBefore   time.Time
+	MaxRows       int32
+}
+
+func (q *Queries) ListUnprocessedMediaIDs(ctx context.Context, arg ListUnprocessedMediaIDsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listUnprocessedMediaIDs, arg.CreatedBefore, arg.StaleBefore, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markMediaProcessed = `-- name: MarkMediaProcessed :exec
+UPDATE media SET processed_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) MarkMediaProcessed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markMediaProcessed, id)
+	return err
+}
+
+const upsertMediaVariant = `-- name: UpsertMediaVariant :exec
+INSERT INTO media_variants (media_id, name, created_at, blob_key, content_type, width, height, blurhash)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+ON CONFLICT (media_id, name) DO UPDATE
+SET blob_key = EXCLUDED.blob_key,
+content_type = EXCLUDED.content_type,
+width = EXCLUDED.width,
+height = EXCLUDED.height,
+blurhash = EXCLUDED.blurhash
+`
+
+type UpsertMediaVariantParams struct {
+	MediaID     uuid.UUID
+	Name        string
+	BlobKey     string
+	ContentType string
+	Width       int32
+	Height      int32
+	Blurhash    string
+}
+
+func (q *Queries) UpsertMediaVariant(ctx context.Context, arg UpsertMediaVariantParams) error {
+	_, err := q.db.ExecContext(ctx, upsertMediaVariant,
+		arg.MediaID,
+		arg.Name,
+		arg.BlobKey,
+		arg.ContentType,
+		arg.Width,
+		arg.Height,
+		arg.Blurhash,
+	)
+	return err
+}
@@ -0,0 +1,109 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: messages.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createMessage = `-- name: CreateMessage :one
+INSERT INTO messages (id, conversation_id, sender_id, created_at, body, seq)
+VALUES (
+    gen_random_uuid (),
+    $1,
+    $2,
+    NOW(),
+    $3,
+    $4
+)
+RETURNING id, conversation_id, sender_id, created_at, body, seq
+`
+
+type CreateMessageParams struct {
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	Body           string
+	Seq            int64
+}
+
+func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
+	row := q.db.QueryRowContext(ctx, createMessage,
+		arg.ConversationID,
+		arg.SenderID,
+		arg.Body,
+		arg.Seq,
+	)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ConversationID,
+		&i.SenderID,
+		&i.CreatedAt,
+		&i.Body,
+		&i.Seq,
+	)
+	return i, err
+}
+
+const listMessages = `-- name: ListMessages :many
+SELECT id, conversation_id, sender_id, created_at, body, seq FROM messages
+WHERE conversation_id = $1
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = $2 AND blocks.blocked_id = messages.sender_id)
+    OR (blocks.blocker_id = messages.sender_id AND blocks.blocked_id = $2)
+)
+AND (created_at, id) < ($3::timestamp, $4::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $5
+`
+
+type ListMessagesParams struct {
+	ConversationID  uuid.UUID
+	ViewerID        uuid.UUID
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+func (q *Queries) ListMessages(ctx context.Context, arg ListMessagesParams) ([]Message, error) {
+	rows, err := q.db.QueryContext(ctx, listMessages,
+		arg.ConversationID,
+		arg.ViewerID,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Message
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ConversationID,
+			&i.SenderID,
+			&i.CreatedAt,
+			&i.Body,
+			&i.Seq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -11,12 +11,240 @@ import (
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID       uuid.UUID
+	ChirpID      uuid.UUID
+	CreatedAt    time.Time
+	CollectionID uuid.NullUUID
+}
+
 type Chirp struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	Body             string
+	UserID           uuid.UUID
+	Search           string
+	InReplyTo        uuid.NullUUID
+	DeletedAt        sql.NullTime
+	Kind             string
+	ReferenceChirpID uuid.NullUUID
+	EditedAt         sql.NullTime
+	CreatedXid       string
+}
+
+type ChirpFlag struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	CreatedAt  time.Time
+	Source     string
+	Reason     string
+	ResolvedAt sql.NullTime
+	ReporterID uuid.NullUUID
+	Details    string
+	ResolvedBy uuid.NullUUID
+	Resolution sql.NullString
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpLike struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMedia struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int16
+}
+
+type ChirpMention struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
 	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	Body      string
+	CreatedAt time.Time
+}
+
+type Collection struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	Name      string
+	Position  int32
+}
+
+type Conversation struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string
+	DirectKey sql.NullString
+	LastSeq   int64
+}
+
+type ConversationMember struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadSeq    int64
+}
+
+type Draft struct {
+	ID               uuid.UUID
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	UserID           uuid.UUID
+	Body             string
+	InReplyTo        uuid.NullUUID
+	Kind             string
+	ReferenceChirpID uuid.NullUUID
+	PublishAt        sql.NullTime
+	MediaIds         []uuid.UUID
+	RetryAt          sql.NullTime
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Tag       string
+}
+
+type HiddenUser struct {
+	ViewerID uuid.UUID
+	HiddenID uuid.UUID
+}
+
+type Media struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UserID      uuid.UUID
+	BlobKey     string
+	ContentType string
+	Width       int32
+	Height      int32
+	SizeBytes   int64
+	ClaimedAt   sql.NullTime
+	ProcessedAt sql.NullTime
+}
+
+type MediaVariant struct {
+	MediaID     uuid.UUID
+	Name        string
+	CreatedAt   time.Time
+	BlobKey     string
+	ContentType string
+	Width       int32
+	Height      int32
+	Blurhash    string
+}
+
+type Message struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	CreatedAt      time.Time
+	Body           string
+	Seq            int64
+}
+
+type ModerationAction struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	ActorID       uuid.NullUUID
+	Action        string
+	ChirpID       uuid.NullUUID
+	TargetUserID  uuid.NullUUID
+	Note          string
+	FlagsResolved int32
+}
+
+type ModerationRule struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Kind      string
+	Value     string
+	Action    string
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	RecipientID uuid.UUID
+	ActorID     uuid.UUID
+	Type        string
+	ChirpID     uuid.NullUUID
+	GroupKey    string
+	ReadAt      sql.NullTime
+}
+
+type NotificationOptOut struct {
+	UserID    uuid.UUID
+	Type      string
+	CreatedAt time.Time
+}
+
+type PinnedChirp struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	Position  int32
+}
+
+type Poll struct {
+	ChirpID        uuid.UUID
+	CreatedAt      time.Time
+	MultipleChoice bool
+	ExpiresAt      time.Time
+}
+
+type PollBallot struct {
+	ChirpID   uuid.UUID
 	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type PollBallotChoice struct {
+	ChirpID  uuid.UUID
+	UserID   uuid.UUID
+	OptionID uuid.UUID
+}
+
+type PollOption struct {
+	ID       uuid.UUID
+	ChirpID  uuid.UUID
+	Position int16
+	Text     string
 }
 
 type RefreshToken struct {
@@ -35,4 +263,10 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	Handle         sql.NullString
+	DisplayName    string
+	Bio            string
+	AvatarUrl      string
+	Role           string
+	BannedAt       sql.NullTime
 }
@@ -0,0 +1,311 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: moderation.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createChirpFlag = `-- name: CreateChirpFlag :exec
+INSERT INTO chirp_flags (id, chirp_id, created_at, source, reason)
+VALUES (
+    gen_random_uuid (),
+    $1,
+    NOW(),
+    $2,
+    $3
+)
+`
+
+type CreateChirpFlagParams struct {
+	ChirpID uuid.UUID
+	Source  string
+	Reason  string
+}
+
+func (q *Queries) CreateChirpFlag(ctx context.Context, arg CreateChirpFlagParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpFlag, arg.ChirpID, arg.Source, arg.Reason)
+	return err
+}
+
+const createChirpReport = `-- name: CreateChirpReport :one
+INSERT INTO chirp_flags (id, chirp_id, created_at, source, reason, reporter_id, details)
+VALUES (
+    gen_random_uuid (),
+    $1,
+    NOW(),
+    'report',
+    $2,
+    $3::uuid,
+    $4
+)
+RETURNING id, chirp_id, created_at, source, reason, resolved_at, reporter_id, details, resolved_by, resolution
+`
+
+type CreateChirpReportParams struct {
+	ChirpID    uuid.UUID
+	Reason     string
+	ReporterID uuid.UUID
+	Details    string
+}
+
+func (q *Queries) CreateChirpReport(ctx context.Context, arg CreateChirpReportParams) (ChirpFlag, error) {
+	row := q.db.QueryRowContext(ctx, createChirpReport,
+		arg.ChirpID,
+		arg.Reason,
+		arg.ReporterID,
+		arg.Details,
+	)
+	var i ChirpFlag
+	err := row.Scan(
+		&i.ID,
+		&i.ChirpID,
+		&i.CreatedAt,
+		&i.Source,
+		&i.Reason,
+		&i.ResolvedAt,
+		&i.ReporterID,
+		&i.Details,
+		&i.ResolvedBy,
+		&i.Resolution,
+	)
+	return i, err
+}
+
+const createModerationAction = `-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, actor_id, action, chirp_id, target_user_id, note, flags_resolved)
+VALUES (
+    gen_random_uuid (),
+    NOW(),
+    $1::uuid,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING id, created_at, actor_id, action, chirp_id, target_user_id, note, flags_resolved
+`
+
+type CreateModerationActionParams struct {
+	ActorID       uuid.UUID
+	Action        string
+	ChirpID       uuid.NullUUID
+	TargetUserID  uuid.NullUUID
+	Note          string
+	FlagsResolved int32
+}
+
+func (q *Queries) CreateModerationAction(ctx context.Context, arg CreateModerationActionParams) (ModerationAction, error) {
+	row := q.db.QueryRowContext(ctx, createModerationAction,
+		arg.ActorID,
+		arg.Action,
+		arg.ChirpID,
+		arg.TargetUserID,
+		arg.Note,
+		arg.FlagsResolved,
+	)
+	var i ModerationAction
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.ActorID,
+		&i.Action,
+		&i.ChirpID,
+		&i.TargetUserID,
+		&i.Note,
+		&i.FlagsResolved,
+	)
+	return i, err
+}
+
+const listModerationActions = `-- name: ListModerationActions :many
+SELECT id, created_at, actor_id, action, chirp_id, target_user_id, note, flags_resolved FROM moderation_actions
+WHERE (created_at, id) < ($1::timestamp, $2::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $3
+`
+
+type ListModerationActionsParams struct {
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+func (q *Queries) ListModerationActions(ctx context.Context, arg ListModerationActionsParams) ([]ModerationAction, error) {
+	rows, err := q.db.QueryContext(ctx, listModerationActions, arg.BeforeCreatedAt, arg.BeforeID, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ModerationAction
+	for rows.Next() {
+		var i ModerationAction
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ActorID,
+			&i.Action,
+			&i.ChirpID,
+			&i.TargetUserID,
+			&i.Note,
+			&i.FlagsResolved,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listModerationRules = `-- name: ListModerationRules :many
+SELECT id, created_at, kind, value, action FROM moderation_rules ORDER BY created_at, id
+`
+
+func (q *Queries) ListModerationRules(ctx context.Context) ([]ModerationRule, error) {
+	rows, err := q.db.QueryContext(ctx, listModerationRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ModerationRule
+	for rows.Next() {
+		var i ModerationRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Kind,
+			&i.Value,
+			&i.Action,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenFlaggedChirps = `-- name: ListOpenFlaggedChirps :many
+SELECT chirp_id, MIN(created_at)::timestamp AS first_flagged_at
+FROM chirp_flags
+WHERE resolved_at IS NULL
+GROUP BY chirp_id
+HAVING (MIN(created_at), chirp_id) > ($1::timestamp, $2::uuid)
+ORDER BY first_flagged_at, chirp_id
+LIMIT $3
+`
+
+type ListOpenFlaggedChirpsParams struct {
+	AfterCreatedAt time.Time
+	AfterID        uuid.UUID
+	MaxRows        int32
+}
+
+type ListOpenFlaggedChirpsRow struct {
+	ChirpID        uuid.UUID
+	FirstFlaggedAt time.Time
+}
+
+func (q *Queries) ListOpenFlaggedChirps(ctx context.Context, arg ListOpenFlaggedChirpsParams) ([]ListOpenFlaggedChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOpenFlaggedChirps, arg.AfterCreatedAt, arg.AfterID, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpenFlaggedChirpsRow
+	for rows.Next() {
+		var i ListOpenFlaggedChirpsRow
+		if err := rows.Scan(&i.ChirpID, &i.FirstFlaggedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenFlagsByChirpIDs = `-- name: ListOpenFlagsByChirpIDs :many
+SELECT id, chirp_id, created_at, source, reason, resolved_at, reporter_id, details, resolved_by, resolution FROM chirp_flags
+WHERE chirp_id = ANY($1::uuid[]) AND resolved_at IS NULL
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListOpenFlagsByChirpIDs(ctx context.Context, chirpIds []uuid.UUID) ([]ChirpFlag, error) {
+	rows, err := q.db.QueryContext(ctx, listOpenFlagsByChirpIDs, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpFlag
+	for rows.Next() {
+		var i ChirpFlag
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.CreatedAt,
+			&i.Source,
+			&i.Reason,
+			&i.ResolvedAt,
+			&i.ReporterID,
+			&i.Details,
+			&i.ResolvedBy,
+			&i.Resolution,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resolveChirpFlags = `-- name: ResolveChirpFlags :execrows
+UPDATE chirp_flags
+SET resolved_at = NOW(),
+resolved_by = $1::uuid,
+resolution = $2::text
+WHERE chirp_id = $3 AND resolved_at IS NULL
+`
+
+type ResolveChirpFlagsParams struct {
+	ResolvedBy uuid.UUID
+	Resolution string
+	ChirpID    uuid.UUID
+}
+
+func (q *Queries) ResolveChirpFlags(ctx context.Context, arg ResolveChirpFlagsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resolveChirpFlags, arg.ResolvedBy, arg.Resolution, arg.ChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
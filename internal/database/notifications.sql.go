@@ -0,0 +1,271 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: notifications.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const countUnreadNotificationGroups = `-- name: CountUnreadNotificationGroups :one
+SELECT COUNT(DISTINCT group_key) FROM notifications
+WHERE recipient_id = $1
+AND read_at IS NULL
+AND created_at > $2::timestamp
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $1 AND hidden_users.hidden_id = notifications.actor_id
+)
+AND (
+    chirp_id IS NULL
+    OR EXISTS (SELECT 1 FROM chirps WHERE chirps.id = notifications.chirp_id AND chirps.deleted_at IS NULL)
+)
+`
+
+type CountUnreadNotificationGroupsParams struct {
+	UserID uuid.UUID
+	Since  time.Time
+}
+
+// Counts the groups ListNotificationGroups lists as unread.
+func (q *Queries) CountUnreadNotificationGroups(ctx context.Context, arg CountUnreadNotificationGroupsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnreadNotificationGroups, arg.UserID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createNotification = `-- name: CreateNotification :exec
+INSERT INTO notifications (id, created_at, recipient_id, actor_id, type, chirp_id, group_key)
+SELECT
+    gen_random_uuid (),
+    NOW(),
+    $1::uuid,
+    $2::uuid,
+    $3::text,
+    $4::uuid,
+    $5::text
+WHERE NOT EXISTS (
+    SELECT 1 FROM notification_opt_outs
+    WHERE notification_opt_outs.user_id = $1::uuid
+    AND notification_opt_outs.type = $3::text
+)
+ON CONFLICT DO NOTHING
+`
+
+type CreateNotificationParams struct {
+	RecipientID uuid.UUID
+	ActorID     uuid.UUID
+	Type        string
+	ChirpID     uuid.NullUUID
+	GroupKey    string
+}
+
+// Does nothing when the recipient opted out of the type, or already has
+// the same event.
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) error {
+	_, err := q.db.ExecContext(ctx, createNotification,
+		arg.RecipientID,
+		arg.ActorID,
+		arg.Type,
+		arg.ChirpID,
+		arg.GroupKey,
+	)
+	return err
+}
+
+const createNotificationOptOut = `-- name: CreateNotificationOptOut :exec
+INSERT INTO notification_opt_outs (user_id, type, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING
+`
+
+type CreateNotificationOptOutParams struct {
+	UserID uuid.UUID
+	Type   string
+}
+
+func (q *Queries) CreateNotificationOptOut(ctx context.Context, arg CreateNotificationOptOutParams) error {
+	_, err := q.db.ExecContext(ctx, createNotificationOptOut, arg.UserID, arg.Type)
+	return err
+}
+
+const deleteNotificationOptOut = `-- name: DeleteNotificationOptOut :exec
+DELETE FROM notification_opt_outs WHERE user_id = $1 AND type = $2
+`
+
+type DeleteNotificationOptOutParams struct {
+	UserID uuid.UUID
+	Type   string
+}
+
+func (q *Queries) DeleteNotificationOptOut(ctx context.Context, arg DeleteNotificationOptOutParams) error {
+	_, err := q.db.ExecContext(ctx, deleteNotificationOptOut, arg.UserID, arg.Type)
+	return err
+}
+
+const listNotificationGroups = `-- name: ListNotificationGroups :many
+SELECT
+    notifications.id,
+    notifications.created_at,
+    notifications.type,
+    notifications.group_key,
+    notifications.chirp_id,
+    (notifications.read_at IS NULL)::boolean AS unread,
+    group_events.actor_count,
+    group_events.actor_ids::uuid[] AS actor_ids
+FROM notifications
+CROSS JOIN LATERAL (
+    SELECT
+        COUNT(*) AS actor_count,
+        (ARRAY_AGG(actor_id ORDER BY created_at DESC, id DESC))[1:$1::int] AS actor_ids
+    FROM (
+        SELECT event.id, event.created_at, event.actor_id FROM notifications AS event
+        WHERE event.recipient_id = notifications.recipient_id
+        AND event.group_key = notifications.group_key
+        AND (event.read_at IS NULL) = (notifications.read_at IS NULL)
+        AND event.created_at > $2::timestamp
+        AND NOT EXISTS (
+            SELECT 1 FROM hidden_users
+            WHERE hidden_users.viewer_id = $3 AND hidden_users.hidden_id = event.actor_id
+        )
+        ORDER BY event.created_at DESC, event.id DESC
+        LIMIT $4
+    ) AS events
+) AS group_events
+WHERE notifications.recipient_id = $3
+AND notifications.created_at > $2::timestamp
+AND (notifications.created_at, notifications.id) < ($5::timestamp, $6::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $3 AND hidden_users.hidden_id = notifications.actor_id
+)
+AND (
+    notifications.chirp_id IS NULL
+    OR EXISTS (SELECT 1 FROM chirps WHERE chirps.id = notifications.chirp_id AND chirps.deleted_at IS NULL)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM notifications AS newer
+    WHERE newer.recipient_id = notifications.recipient_id
+    AND newer.group_key = notifications.group_key
+    AND (newer.read_at IS NULL) = (notifications.read_at IS NULL)
+    AND (newer.created_at, newer.id) > (notifications.created_at, notifications.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = $3 AND hidden_users.hidden_id = newer.actor_id
+    )
+)
+ORDER BY notifications.created_at DESC, notifications.id DESC
+LIMIT $7
+`
+
+type ListNotificationGroupsParams struct {
+	MaxActors       int32
+	Since           time.Time
+	UserID          uuid.UUID
+	MaxGroupEvents  int32
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	MaxRows         int32
+}
+
+type ListNotificationGroupsRow struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	Type       string
+	GroupKey   string
+	ChirpID    uuid.NullUUID
+	Unread     bool
+	ActorCount int64
+	ActorIds   []uuid.UUID
+}
+
+// One row per group, most recent activity first. Unread events are grouped
+// apart from read ones, so new activity shows up as a fresh entry. Events
+// by users the recipient blocked or muted, or about deleted chirps, are
+// left out. A group is identified by its newest event, so pages walk the
+// recipient's events newest first and keep those with no newer event in
+// their group. Only the newest max_group_events events of a group since
+// since are counted.
+func (q *Queries) ListNotificationGroups(ctx context.Context, arg ListNotificationGroupsParams) ([]ListNotificationGroupsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listNotificationGroups,
+		arg.MaxActors,
+		arg.Since,
+		arg.UserID,
+		arg.MaxGroupEvents,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.MaxRows,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNotificationGroupsRow
+	for rows.Next() {
+		var i ListNotificationGroupsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Type,
+			&i.GroupKey,
+			&i.ChirpID,
+			&i.Unread,
+			&i.ActorCount,
+			pq.Array(&i.ActorIds),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotificationOptOuts = `-- name: ListNotificationOptOuts :many
+SELECT type FROM notification_opt_outs WHERE user_id = $1
+`
+
+func (q *Queries) ListNotificationOptOuts(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listNotificationOptOuts, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var type_ string
+		if err := rows.Scan(&type_); err != nil {
+			return nil, err
+		}
+		items = append(items, type_)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markNotificationsRead = `-- name: MarkNotificationsRead :exec
+UPDATE notifications SET read_at = NOW()
+WHERE recipient_id = $1 AND read_at IS NULL
+`
+
+func (q *Queries) MarkNotificationsRead(ctx context.Context, recipientID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markNotificationsRead, recipientID)
+	return err
+}
@@ -0,0 +1,123 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: pinned_chirps.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const countPinnedChirps = `-- name: CountPinnedChirps :one
+SELECT COUNT(*) FROM pinned_chirps
+JOIN chirps ON chirps.id = pinned_chirps.chirp_id
+WHERE pinned_chirps.user_id = $1
+AND pinned_chirps.chirp_id <> $2
+AND chirps.deleted_at IS NULL
+`
+
+type CountPinnedChirpsParams struct {
+	UserID        uuid.UUID
+	ExceptChirpID uuid.UUID
+}
+
+// Pins of deleted chirps do not use up a slot.
+func (q *Queries) CountPinnedChirps(ctx context.Context, arg CountPinnedChirpsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPinnedChirps, arg.UserID, arg.ExceptChirpID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createPinnedChirp = `-- name: CreatePinnedChirp :exec
+INSERT INTO pinned_chirps (user_id, chirp_id, created_at, position)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    (SELECT COALESCE(MAX(position) + 1, 0) FROM pinned_chirps WHERE user_id = $1)
+)
+ON CONFLICT DO NOTHING
+`
+
+type CreatePinnedChirpParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) CreatePinnedChirp(ctx context.Context, arg CreatePinnedChirpParams) error {
+	_, err := q.db.ExecContext(ctx, createPinnedChirp, arg.UserID, arg.ChirpID)
+	return err
+}
+
+const deletePinnedChirp = `-- name: DeletePinnedChirp :exec
+DELETE FROM pinned_chirps WHERE user_id = $1 AND chirp_id = $2
+`
+
+type DeletePinnedChirpParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) DeletePinnedChirp(ctx context.Context, arg DeletePinnedChirpParams) error {
+	_, err := q.db.ExecContext(ctx, deletePinnedChirp, arg.UserID, arg.ChirpID)
+	return err
+}
+
+const listPinnedChirps = `-- name: ListPinnedChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.search, chirps.in_reply_to, chirps.deleted_at, chirps.kind, chirps.reference_chirp_id, chirps.edited_at, chirps.created_xid FROM pinned_chirps
+JOIN chirps ON chirps.id = pinned_chirps.chirp_id
+WHERE pinned_chirps.user_id = $1
+AND chirps.deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = $2 AND hidden_users.hidden_id = chirps.user_id
+)
+ORDER BY pinned_chirps.position, pinned_chirps.created_at
+LIMIT $3
+`
+
+type ListPinnedChirpsParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.UUID
+	MaxRows  int32
+}
+
+func (q *Queries) ListPinnedChirps(ctx context.Context, arg ListPinnedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listPinnedChirps, arg.UserID, arg.ViewerID, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.Search,
+			&i.InReplyTo,
+			&i.DeletedAt,
+			&i.Kind,
+			&i.ReferenceChirpID,
+			&i.EditedAt,
+			&i.CreatedXid,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
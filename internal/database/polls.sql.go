@@ -0,0 +1,225 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.28.0
+// source: polls.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createPoll = `-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, multiple_choice, expires_at)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3
+)
+`
+
+type CreatePollParams struct {
+	ChirpID        uuid.UUID
+	MultipleChoice bool
+	ExpiresAt      time.Time
+}
+
+func (q *Queries) CreatePoll(ctx context.Context, arg CreatePollParams) error {
+	_, err := q.db.ExecContext(ctx, createPoll, arg.ChirpID, arg.MultipleChoice, arg.ExpiresAt)
+	return err
+}
+
+const createPollBallot = `-- name: CreatePollBallot :exec
+INSERT INTO poll_ballots (chirp_id, user_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+`
+
+type CreatePollBallotParams struct {
+	ChirpID uuid.UUID
+	UserID  uuid.UUID
+}
+
+func (q *Queries) CreatePollBallot(ctx context.Context, arg CreatePollBallotParams) error {
+	_, err := q.db.ExecContext(ctx, createPollBallot, arg.ChirpID, arg.UserID)
+	return err
+}
+
+const createPollBallotChoice = `-- name: CreatePollBallotChoice :exec
+INSERT INTO poll_ballot_choices (chirp_id, user_id, option_id)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+`
+
+type CreatePollBallotChoiceParams struct {
+	ChirpID  uuid.UUID
+	UserID   uuid.UUID
+	OptionID uuid.UUID
+}
+
+func (q *Queries) CreatePollBallotChoice(ctx context.Context, arg CreatePollBallotChoiceParams) error {
+	_, err := q.db.ExecContext(ctx, createPollBallotChoice, arg.ChirpID, arg.UserID, arg.OptionID)
+	return err
+}
+
+const createPollOption = `-- name: CreatePollOption :exec
+INSERT INTO poll_options (id, chirp_id, position, text)
+VALUES (
+    gen_random_uuid (),
+    $1,
+    $2,
+    $3
+)
+`
+
+type CreatePollOptionParams struct {
+	ChirpID  uuid.UUID
+	Position int16
+	Text     string
+}
+
+func (q *Queries) CreatePollOption(ctx context.Context, arg CreatePollOptionParams) error {
+	_, err := q.db.ExecContext(ctx, createPollOption, arg.ChirpID, arg.Position, arg.Text)
+	return err
+}
+
+const getPollsByChirpIDs = `-- name: GetPollsByChirpIDs :many
+SELECT polls.chirp_id, polls.created_at, polls.multiple_choice, polls.expires_at, (
+    SELECT COUNT(*) FROM poll_ballots WHERE poll_ballots.chirp_id = polls.chirp_id
+) AS voters
+FROM polls
+WHERE polls.chirp_id = ANY($1::uuid[])
+`
+
+type GetPollsByChirpIDsRow struct {
+	ChirpID        uuid.UUID
+	CreatedAt      time.Time
+	MultipleChoice bool
+	ExpiresAt      time.Time
+	Voters         int64
+}
+
+func (q *Queries) GetPollsByChirpIDs(ctx context.Context, chirpIds []uuid.UUID) ([]GetPollsByChirpIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPollsByChirpIDs, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPollsByChirpIDsRow
+	for rows.Next() {
+		var i GetPollsByChirpIDsRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.CreatedAt,
+			&i.MultipleChoice,
+			&i.ExpiresAt,
+			&i.Voters,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPollChoicesByUser = `-- name: ListPollChoicesByUser :many
+SELECT chirp_id, option_id FROM poll_ballot_choices
+WHERE user_id = $1
+AND chirp_id = ANY($2::uuid[])
+`
+
+type ListPollChoicesByUserParams struct {
+	UserID   uuid.UUID
+	ChirpIds []uuid.UUID
+}
+
+type ListPollChoicesByUserRow struct {
+	ChirpID  uuid.UUID
+	OptionID uuid.UUID
+}
+
+func (q *Queries) ListPollChoicesByUser(ctx context.Context, arg ListPollChoicesByUserParams) ([]ListPollChoicesByUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPollChoicesByUser, arg.UserID, pq.Array(arg.ChirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPollChoicesByUserRow
+	for rows.Next() {
+		var i ListPollChoicesByUserRow
+		if err := rows.Scan(&i.ChirpID, &i.OptionID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPollOptionsByChirpIDs = `-- name: ListPollOptionsByChirpIDs :many
+SELECT poll_options.id, poll_options.chirp_id, poll_options.position, poll_options.text, COUNT(poll_ballot_choices.option_id) AS votes
+FROM poll_options
+LEFT JOIN poll_ballot_choices ON poll_ballot_choices.option_id = poll_options.id
+WHERE poll_options.chirp_id = ANY($1::uuid[])
+GROUP BY poll_options.id
+ORDER BY poll_options.chirp_id, poll_options.position
+`
+
+type ListPollOptionsByChirpIDsRow struct {
+	ID       uuid.UUID
+	ChirpID  uuid.UUID
+	Position int16
+	Text     string
+	Votes    int64
+}
+
+func (q *Queries) ListPollOptionsByChirpIDs(ctx context.Context, chirpIds []uuid.UUID) ([]ListPollOptionsByChirpIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPollOptionsByChirpIDs, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPollOptionsByChirpIDsRow
+	for rows.Next() {
+		var i ListPollOptionsByChirpIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.Position,
+			&i.Text,
+			&i.Votes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,118 @@
+package media
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// Blurhash encodes img as a BlurHash (https://blurha.sh) with xComponents
+// by yComponents cosine components, each between 1 and 9. Clients draw it
+// as a placeholder while the real image loads.
+func Blurhash(img image.Image, xComponents, yComponents int) string {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	// Convert once up front; the sums below visit every pixel once per
+	// component.
+	linear := make([][3]float64, w*h)
+	for y := range h {
+		for x := range w {
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			linear[y*w+x] = [3]float64{
+				srgbToLinear(r >> 8),
+				srgbToLinear(g >> 8),
+				srgbToLinear(bl >> 8),
+			}
+		}
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := range yComponents {
+		cosY := make([]float64, h)
+		for y := range h {
+			cosY[y] = math.Cos(math.Pi * float64(j) * float64(y) / float64(h))
+		}
+		for i := range xComponents {
+			cosX := make([]float64, w)
+			for x := range w {
+				cosX[x] = math.Cos(math.Pi * float64(i) * float64(x) / float64(w))
+			}
+
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var sum [3]float64
+			for y := range h {
+				for x := range w {
+					basis := cosX[x] * cosY[y]
+					p := linear[y*w+x]
+					sum[0] += basis * p[0]
+					sum[1] += basis * p[1]
+					sum[2] += basis * p[2]
+				}
+			}
+
+			scale := normalisation / float64(w*h)
+			factors = append(factors, [3]float64{sum[0] * scale, sum[1] * scale, sum[2] * scale})
+		}
+	}
+
+	hash := strings.Builder{}
+	hash.WriteString(encode83((xComponents-1)+(yComponents-1)*9, 1))
+
+	dc, ac := factors[0], factors[1:]
+	maxValue := 1.0
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, f := range ac {
+			actualMax = max(actualMax, math.Abs(f[0]), math.Abs(f[1]), math.Abs(f[2]))
+		}
+		quantisedMax := int(max(0, min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantisedMax+1) / 166
+		hash.WriteString(encode83(quantisedMax, 1))
+	} else {
+		hash.WriteString(encode83(0, 1))
+	}
+
+	hash.WriteString(encode83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+	for _, f := range ac {
+		hash.WriteString(encode83(quantiseAC(f[0], maxValue)*19*19+quantiseAC(f[1], maxValue)*19+quantiseAC(f[2], maxValue), 2))
+	}
+
+	return hash.String()
+}
+
+func quantiseAC(v, maxValue float64) int {
+	signPow := math.Copysign(math.Sqrt(math.Abs(v/maxValue)), v)
+	return int(max(0, min(18, math.Floor(signPow*9+9.5))))
+}
+
+func srgbToLinear(v uint32) float64 {
+	f := float64(v) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	v = max(0, min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func encode83(value, length int) string {
+	out := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		out[i] = base83Chars[value%83]
+		value /= 83
+	}
+	return string(out)
+}
@@ -0,0 +1,64 @@
+package media
+
+// gifFrameCount counts the frames of a GIF by walking its blocks, without
+// decompressing any of them. ok is false if the file is malformed.
+func gifFrameCount(data []byte) (n int, ok bool) {
+	// Header and logical screen descriptor.
+	if len(data) < 13 {
+		return 0, false
+	}
+	i := 13
+	if data[10]&0x80 != 0 {
+		i += 3 << (data[10]&0x07 + 1)
+	}
+
+	for i < len(data) {
+		switch data[i] {
+		case 0x21:
+			// Extension: a label, then data sub-blocks.
+			if i+2 > len(data) {
+				return 0, false
+			}
+			i, ok = skipGIFSubBlocks(data, i+2)
+			if !ok {
+				return 0, false
+			}
+		case 0x2C:
+			// Image descriptor, optional local color table, LZW
+			// minimum code size, then data sub-blocks.
+			if i+10 > len(data) {
+				return 0, false
+			}
+			packed := data[i+9]
+			i += 10
+			if packed&0x80 != 0 {
+				i += 3 << (packed&0x07 + 1)
+			}
+			i, ok = skipGIFSubBlocks(data, i+1)
+			if !ok {
+				return 0, false
+			}
+			n++
+		case 0x3B:
+			// Trailer.
+			return n, true
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// skipGIFSubBlocks returns the offset just past the sub-blocks starting
+// at i, which end with a zero-length block.
+func skipGIFSubBlocks(data []byte, i int) (int, bool) {
+	for i < len(data) {
+		size := int(data[i])
+		i++
+		if size == 0 {
+			return i, true
+		}
+		i += size
+	}
+	return 0, false
+}
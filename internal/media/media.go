@@ -0,0 +1,106 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// DefaultMaxPixels is a sensible cap on width*height for Sanitize.
+const DefaultMaxPixels = 40_000_000
+
+const jpegQuality = 90
+
+var (
+	ErrUnsupportedType = errors.New("unsupported media type")
+	ErrInvalidImage    = errors.New("invalid image")
+	ErrTooManyPixels   = errors.New("image has too many pixels")
+)
+
+// Image is an upload that has been checked and re-encoded.
+type Image struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+// Sanitize sniffs the type of data from its bytes rather than trusting
+// the client, accepts JPEG, PNG and GIF, and re-encodes the image. Only
+// pixels survive re-encoding, so EXIF and every other kind of metadata
+// is dropped. The EXIF orientation of a JPEG is applied first, so photos
+// still display the right way up.
+//
+// Images of more than maxPixels pixels are rejected before any pixel
+// data is decoded, so a small file cannot decompress into an image that
+// exhausts memory. For a GIF the limit covers all of its frames together,
+// which are counted by walking the file's blocks.
+func Sanitize(data []byte, maxPixels int) (Image, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return Image{}, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrInvalidImage
+	}
+	if config.Width*config.Height > maxPixels {
+		return Image{}, ErrTooManyPixels
+	}
+
+	out := bytes.Buffer{}
+	result := Image{ContentType: contentType}
+
+	switch contentType {
+	case "image/gif":
+		frames, ok := gifFrameCount(data)
+		if !ok {
+			return Image{}, ErrInvalidImage
+		}
+		if frames*config.Width*config.Height > maxPixels {
+			return Image{}, ErrTooManyPixels
+		}
+		// Decode every frame so animations are kept.
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, ErrInvalidImage
+		}
+		if err := gif.EncodeAll(&out, g); err != nil {
+			return Image{}, err
+		}
+		result.Ext = ".gif"
+		result.Width, result.Height = g.Config.Width, g.Config.Height
+	case "image/png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, ErrInvalidImage
+		}
+		if err := png.Encode(&out, img); err != nil {
+			return Image{}, err
+		}
+		result.Ext = ".png"
+		result.Width, result.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	case "image/jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, ErrInvalidImage
+		}
+		img = orient(img, jpegOrientation(data))
+		if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return Image{}, err
+		}
+		result.Ext = ".jpg"
+		result.Width, result.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	}
+
+	result.Data = out.Bytes()
+	return result, nil
+}
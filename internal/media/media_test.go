@@ -0,0 +1,158 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 40), G: uint8(y * 40), A: 255})
+		}
+	}
+	return img
+}
+
+// withOrientation inserts an EXIF segment holding only an orientation tag
+// right after the SOI marker of a JPEG.
+func withOrientation(jpg []byte, orientation byte) []byte {
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08" +
+		"\x00\x01" +
+		"\x01\x12\x00\x03\x00\x00\x00\x01\x00" + string([]byte{orientation}) + "\x00\x00" +
+		"\x00\x00\x00\x00")
+	length := len(exif) + 2
+
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1, byte(length >> 8), byte(length)}
+	out = append(out, exif...)
+	return append(out, jpg[2:]...)
+}
+
+func TestSanitize_PNG(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, testImage(3, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := Sanitize(buf.Bytes(), DefaultMaxPixels)
+	if err != nil {
+		t.Fatalf("Sanitize returned error: %v", err)
+	}
+	if img.ContentType != "image/png" || img.Ext != ".png" || img.Width != 3 || img.Height != 2 {
+		t.Errorf("Unexpected result %+v", img)
+	}
+}
+
+func TestSanitize_JPEGStripsEXIFAndAppliesOrientation(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, testImage(4, 2), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := withOrientation(buf.Bytes(), 6)
+
+	if got := jpegOrientation(data); got != 6 {
+		t.Fatalf("Expected orientation 6, got %d", got)
+	}
+
+	img, err := Sanitize(data, DefaultMaxPixels)
+	if err != nil {
+		t.Fatalf("Sanitize returned error: %v", err)
+	}
+	if img.Width != 2 || img.Height != 4 {
+		t.Errorf("Expected a 2x4 image after rotating, got %dx%d", img.Width, img.Height)
+	}
+	if bytes.Contains(img.Data, []byte("Exif")) {
+		t.Error("Expected EXIF data to be removed")
+	}
+}
+
+func TestSanitize_Rejects(t *testing.T) {
+	if _, err := Sanitize([]byte("<html><body>hi</body></html>"), DefaultMaxPixels); err != ErrUnsupportedType {
+		t.Errorf("Expected ErrUnsupportedType for HTML, got %v", err)
+	}
+
+	truncated := []byte("\x89PNG\r\n\x1a\n\x00\x00")
+	if _, err := Sanitize(truncated, DefaultMaxPixels); err != ErrInvalidImage {
+		t.Errorf("Expected ErrInvalidImage for a truncated PNG, got %v", err)
+	}
+}
+
+func TestSanitize_TooManyPixels(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, testImage(20, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Sanitize(buf.Bytes(), 199); err != ErrTooManyPixels {
+		t.Errorf("Expected ErrTooManyPixels, got %v", err)
+	}
+	if _, err := Sanitize(buf.Bytes(), 200); err != nil {
+		t.Errorf("Expected an image at the limit to pass, got %v", err)
+	}
+}
+
+func testGIF(t *testing.T, frames, w, h int) []byte {
+	t.Helper()
+	g := &gif.GIF{}
+	for range frames {
+		frame := image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.Black, color.White})
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+	buf := bytes.Buffer{}
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGIFFrameCount(t *testing.T) {
+	data := testGIF(t, 3, 4, 4)
+	if n, ok := gifFrameCount(data); !ok || n != 3 {
+		t.Errorf("Expected 3 frames, got %d (ok=%v)", n, ok)
+	}
+	if _, ok := gifFrameCount(data[:len(data)-5]); ok {
+		t.Error("Expected a truncated GIF to be rejected")
+	}
+}
+
+func TestSanitize_GIFFramesCountTowardsPixels(t *testing.T) {
+	data := testGIF(t, 3, 10, 10)
+
+	if _, err := Sanitize(data, 299); err != ErrTooManyPixels {
+		t.Errorf("Expected ErrTooManyPixels, got %v", err)
+	}
+	img, err := Sanitize(data, 300)
+	if err != nil {
+		t.Fatalf("Expected a GIF at the limit to pass, got %v", err)
+	}
+	if img.ContentType != "image/gif" || img.Width != 10 || img.Height != 10 {
+		t.Errorf("Unexpected result %+v", img)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	src := testImage(3, 2)
+	for orientation := 1; orientation <= 8; orientation++ {
+		got := orient(src, orientation).Bounds()
+		wantW, wantH := 3, 2
+		if orientation >= 5 {
+			wantW, wantH = 2, 3
+		}
+		if got.Dx() != wantW || got.Dy() != wantH {
+			t.Errorf("orient(%d): expected %dx%d, got %dx%d", orientation, wantW, wantH, got.Dx(), got.Dy())
+		}
+	}
+
+	// A 90° clockwise turn moves the top-left pixel to the top-right.
+	rotated := orient(src, 6)
+	if rotated.At(1, 0) != src.At(0, 0) {
+		t.Errorf("orient(6): expected top-left pixel at (1, 0)")
+	}
+}
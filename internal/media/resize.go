@@ -0,0 +1,49 @@
+package media
+
+import (
+	"image"
+	"image/color"
+)
+
+// Resize scales img down to fit within a maxSize by maxSize box, keeping
+// its aspect ratio. Each output pixel is the average of the source pixels
+// it covers, which avoids the aliasing of nearest-neighbour sampling when
+// shrinking a lot. Images that already fit are returned unchanged.
+func Resize(img image.Image, maxSize int) image.Image {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw <= maxSize && sh <= maxSize {
+		return img
+	}
+
+	dw, dh := maxSize, maxSize
+	if sw > sh {
+		dh = max(1, sh*maxSize/sw)
+	} else {
+		dw = max(1, sw*maxSize/sh)
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := range dh {
+		y0, y1 := dy*sh/dh, (dy+1)*sh/dh
+		for dx := range dw {
+			x0, x1 := dx*sw/dw, (dx+1)*sw/dw
+
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					pr, pg, pb, pa := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			out.SetRGBA64(dx, dy, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return out
+}
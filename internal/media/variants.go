@@ -0,0 +1,71 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	blurhashXComponents = 4
+	blurhashYComponents = 3
+)
+
+// VariantSpec names a size to generate. The variant fits within a
+// MaxSize by MaxSize box.
+type VariantSpec struct {
+	Name    string
+	MaxSize int
+}
+
+// Variant is a resized copy of an image.
+type Variant struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+	Blurhash    string
+}
+
+// MakeVariants decodes an image produced by Sanitize and generates one
+// variant per spec. JPEGs stay JPEGs; PNGs and GIFs become PNGs so that
+// transparency survives. An animated GIF yields stills of its first
+// frame.
+func MakeVariants(data []byte, specs []VariantSpec) ([]Variant, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	variants := make([]Variant, 0, len(specs))
+	for _, spec := range specs {
+		resized := Resize(img, spec.MaxSize)
+
+		v := Variant{
+			Name:     spec.Name,
+			Width:    resized.Bounds().Dx(),
+			Height:   resized.Bounds().Dy(),
+			Blurhash: Blurhash(resized, blurhashXComponents, blurhashYComponents),
+		}
+
+		out := bytes.Buffer{}
+		if format == "jpeg" {
+			err = jpeg.Encode(&out, resized, &jpeg.Options{Quality: jpegQuality})
+			v.ContentType, v.Ext = "image/jpeg", ".jpg"
+		} else {
+			err = png.Encode(&out, resized)
+			v.ContentType, v.Ext = "image/png", ".png"
+		}
+		if err != nil {
+			return nil, err
+		}
+		v.Data = out.Bytes()
+
+		variants = append(variants, v)
+	}
+
+	return variants, nil
+}
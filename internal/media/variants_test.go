@@ -0,0 +1,94 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"testing"
+)
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		w, h, maxSize int
+		wantW, wantH  int
+	}{
+		{400, 200, 100, 100, 50},
+		{200, 400, 100, 50, 100},
+		{1000, 1, 100, 100, 1},
+		{80, 60, 100, 80, 60},
+	}
+
+	for _, tt := range tests {
+		got := Resize(testImage(tt.w, tt.h), tt.maxSize).Bounds()
+		if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+			t.Errorf("Resize(%dx%d, %d): expected %dx%d, got %dx%d", tt.w, tt.h, tt.maxSize, tt.wantW, tt.wantH, got.Dx(), got.Dy())
+		}
+	}
+}
+
+func TestResize_Averages(t *testing.T) {
+	// Alternating black and white columns average out to grey.
+	img := image.NewGray(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 4 {
+			if x%2 == 0 {
+				img.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+
+	r, _, _, _ := Resize(img, 2).At(0, 0).RGBA()
+	if r>>8 < 120 || r>>8 > 135 {
+		t.Errorf("Expected a mid grey, got %d", r>>8)
+	}
+}
+
+func TestBlurhash_SolidColor(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+
+	// "L" encodes 4x3 components and "TSUA" is a pure white DC term.
+	got := Blurhash(img, 4, 3)
+	if !strings.HasPrefix(got, "L") || got[2:6] != "TSUA" {
+		t.Errorf("Expected size flag L and DC TSUA, got %s", got)
+	}
+}
+
+func TestBlurhash_Length(t *testing.T) {
+	for _, c := range [][2]int{{1, 1}, {4, 3}, {9, 9}} {
+		got := Blurhash(testImage(6, 5), c[0], c[1])
+		if want := 6 + 2*(c[0]*c[1]-1); len(got) != want {
+			t.Errorf("Blurhash(%dx%d components): expected length %d, got %d (%s)", c[0], c[1], want, len(got), got)
+		}
+	}
+}
+
+func TestMakeVariants(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := jpeg.Encode(&buf, testImage(300, 150), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	variants, err := MakeVariants(buf.Bytes(), []VariantSpec{{"thumb", 60}, {"medium", 200}})
+	if err != nil {
+		t.Fatalf("MakeVariants returned error: %v", err)
+	}
+	if len(variants) != 2 {
+		t.Fatalf("Expected 2 variants, got %d", len(variants))
+	}
+
+	thumb, medium := variants[0], variants[1]
+	if thumb.Name != "thumb" || thumb.Width != 60 || thumb.Height != 30 {
+		t.Errorf("Unexpected thumb %s %dx%d", thumb.Name, thumb.Width, thumb.Height)
+	}
+	if medium.Width != 200 || medium.Height != 100 || medium.ContentType != "image/jpeg" {
+		t.Errorf("Unexpected medium %dx%d %s", medium.Width, medium.Height, medium.ContentType)
+	}
+	if thumb.Blurhash == "" || len(thumb.Data) == 0 {
+		t.Error("Expected a blurhash and encoded data")
+	}
+}
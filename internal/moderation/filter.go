@@ -0,0 +1,150 @@
+package moderation
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Action is what happens to a text that matches a rule.
+type Action string
+
+const (
+	// ActionMask replaces the matching word with Mask.
+	ActionMask Action = "mask"
+	// ActionReject refuses the whole text.
+	ActionReject Action = "reject"
+	// ActionReview lets the text through unchanged but flags it for a
+	// moderator.
+	ActionReview Action = "review"
+)
+
+// Mask is what masked words are replaced with.
+const Mask = "****"
+
+var ErrInvalidRule = errors.New("invalid moderation rule")
+
+// Rule is one entry of a rule set. Exactly one of Word and Pattern is
+// set. A Word is normalized and compared with each normalized word of
+// the text; a Pattern is a regular expression matched against each
+// normalized word.
+type Rule struct {
+	Word    string
+	Pattern string
+	Action  Action
+}
+
+func (r Rule) String() string {
+	if r.Pattern != "" {
+		return fmt.Sprintf("%s /%s/", r.Action, r.Pattern)
+	}
+	return fmt.Sprintf("%s %s", r.Action, r.Word)
+}
+
+type pattern struct {
+	re   *regexp.Regexp
+	rule Rule
+}
+
+// Filter is a compiled rule set. It is immutable, so one Filter can be
+// shared by every request and swapped out wholesale on reload.
+type Filter struct {
+	words    map[string][]Rule
+	patterns []pattern
+	size     int
+}
+
+// NewFilter compiles rules, failing on unknown actions, words that
+// normalize to nothing and invalid regular expressions.
+func NewFilter(rules []Rule) (*Filter, error) {
+	f := &Filter{words: map[string][]Rule{}, size: len(rules)}
+
+	for i, rule := range rules {
+		switch rule.Action {
+		case ActionMask, ActionReject, ActionReview:
+		default:
+			return nil, fmt.Errorf("%w: rule %d has unknown action %q", ErrInvalidRule, i+1, rule.Action)
+		}
+
+		switch {
+		case rule.Word != "" && rule.Pattern == "":
+			word := Normalize(rule.Word)
+			if word == "" {
+				return nil, fmt.Errorf("%w: rule %d word %q is empty once normalized", ErrInvalidRule, i+1, rule.Word)
+			}
+			f.words[word] = append(f.words[word], rule)
+		case rule.Pattern != "" && rule.Word == "":
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%w: rule %d: %s", ErrInvalidRule, i+1, err)
+			}
+			f.patterns = append(f.patterns, pattern{re: re, rule: rule})
+		default:
+			return nil, fmt.Errorf("%w: rule %d needs exactly one of a word or a pattern", ErrInvalidRule, i+1)
+		}
+	}
+
+	return f, nil
+}
+
+// Len is the number of rules in the filter.
+func (f *Filter) Len() int {
+	return f.size
+}
+
+// Result is the outcome of checking a text.
+type Result struct {
+	// Text is the input with every masked word replaced by Mask.
+	Text     string
+	Rejected bool
+	Review   bool
+	// Matched lists the rules that matched, in the order they matched.
+	Matched []Rule
+}
+
+// Check runs text through the filter.
+func (f *Filter) Check(text string) Result {
+	result := Result{}
+	out := strings.Builder{}
+	last := 0
+
+	for _, token := range Tokenize(text) {
+		matched := f.match(token.Normalized)
+		if len(matched) == 0 {
+			continue
+		}
+		result.Matched = append(result.Matched, matched...)
+
+		mask := false
+		for _, rule := range matched {
+			switch rule.Action {
+			case ActionMask:
+				mask = true
+			case ActionReject:
+				result.Rejected = true
+			case ActionReview:
+				result.Review = true
+			}
+		}
+		if mask {
+			out.WriteString(text[last:token.Start])
+			out.WriteString(Mask)
+			last = token.End
+		}
+	}
+
+	out.WriteString(text[last:])
+	result.Text = out.String()
+	return result
+}
+
+func (f *Filter) match(word string) []Rule {
+	matched := append([]Rule{}, f.words[word]...)
+	for _, p := range f.patterns {
+		if p.re.MatchString(word) {
+			matched = append(matched, p.rule)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,176 @@
+package moderation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Kerfuffle", "kerfuffle"},
+		{"K3RFUFFL3", "kerfuffle"},
+		{"\uff4b\uff45\uff52\uff46\uff55\uff46\uff46\uff4c\uff45", "kerfuffle"},
+		{"kérfüffle", "kerfuffle"},
+		{"f.o.r.n.a.x", "fornax"},
+		{"$h@rbert", "sharbert"},
+		{"for\u200bnax", "fornax"},
+		{"STRASSE", "strasse"},
+		{"Straße", "strasse"},
+		{"--", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q): expected %q, got %q", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	text := "  Kerfuffle! (fornax), -- $harbert"
+	tokens := Tokenize(text)
+
+	want := []struct {
+		core       string
+		normalized string
+	}{
+		{"Kerfuffle", "kerfuffle"},
+		{"fornax", "fornax"},
+		{"$harbert", "sharbert"},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("Expected %d tokens, got %+v", len(want), tokens)
+	}
+	for i, token := range tokens {
+		if core := text[token.Start:token.End]; core != want[i].core || token.Normalized != want[i].normalized {
+			t.Errorf("Token %d: expected %q/%q, got %q/%q", i, want[i].core, want[i].normalized, core, token.Normalized)
+		}
+	}
+}
+
+func TestFilter_Check(t *testing.T) {
+	f, err := NewFilter([]Rule{
+		{Word: "kerfuffle", Action: ActionMask},
+		{Word: "sharbert", Action: ActionReview},
+		{Pattern: `^f+o+r+n+a+x+$`, Action: ActionReject},
+	})
+	if err != nil {
+		t.Fatalf("NewFilter returned error: %v", err)
+	}
+
+	got := f.Check("What a Kerfuffle! Truly a k3rfuffle.")
+	if got.Text != "What a ****! Truly a ****." || got.Rejected || got.Review || len(got.Matched) != 2 {
+		t.Errorf("Unexpected mask result %+v", got)
+	}
+
+	got = f.Check("just some sharbert, nothing else")
+	if got.Text != "just some sharbert, nothing else" || !got.Review || got.Rejected {
+		t.Errorf("Unexpected review result %+v", got)
+	}
+
+	got = f.Check("FOORNAXXX!")
+	if !got.Rejected {
+		t.Errorf("Expected rejection, got %+v", got)
+	}
+
+	got = f.Check("a perfectly fine chirp")
+	if got.Text != "a perfectly fine chirp" || got.Rejected || got.Review || len(got.Matched) != 0 {
+		t.Errorf("Unexpected clean result %+v", got)
+	}
+}
+
+func TestNewFilter_Invalid(t *testing.T) {
+	for _, rules := range [][]Rule{
+		{{Word: "x", Action: "ban"}},
+		{{Word: "--", Action: ActionMask}},
+		{{Pattern: "(", Action: ActionMask}},
+		{{Word: "x", Pattern: "y", Action: ActionMask}},
+		{{Action: ActionMask}},
+	} {
+		if _, err := NewFilter(rules); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("NewFilter(%+v): expected ErrInvalidRule, got %v", rules, err)
+		}
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	input := `
+# comment
+mask kerfuffle
+  reject /^f+o+r+n+a+x+$/
+review sharbert
+`
+	rules, err := ParseRules(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseRules returned error: %v", err)
+	}
+
+	want := []Rule{
+		{Word: "kerfuffle", Action: ActionMask},
+		{Pattern: "^f+o+r+n+a+x+$", Action: ActionReject},
+		{Word: "sharbert", Action: ActionReview},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("Expected %d rules, got %+v", len(want), rules)
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("Rule %d: expected %+v, got %+v", i, want[i], rules[i])
+		}
+	}
+
+	if _, err := ParseRules(strings.NewReader("mask\n")); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("Expected ErrInvalidRule for a rule without a word, got %v", err)
+	}
+}
+
+func FuzzTokenize(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"Kerfuffle! (fornax), -- $harbert",
+		"ｋｅｒｆｕｆｆｌｅ kérfüffle",
+		"for​nax sharbert\t\n",
+		"\xff\xfe invalid \xc3",
+		"👨‍👩‍👧 🇳🇱 @@@ $$$",
+	} {
+		f.Add(seed)
+	}
+
+	filter, err := NewFilter([]Rule{{Word: "kerfuffle", Action: ActionMask}})
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, text string) {
+		last := 0
+		for _, token := range Tokenize(text) {
+			if token.Start < last || token.Start >= token.End || token.End > len(text) {
+				t.Fatalf("Token %+v out of order or out of bounds (last end %d, len %d)", token, last, len(text))
+			}
+			core := text[token.Start:token.End]
+			if strings.IndexFunc(core, unicode.IsSpace) >= 0 {
+				t.Fatalf("Token %q contains whitespace", core)
+			}
+			if token.Normalized == "" || token.Normalized != Normalize(core) {
+				t.Fatalf("Token %q normalized to %q, expected %q", core, token.Normalized, Normalize(core))
+			}
+			if !utf8.ValidString(token.Normalized) {
+				t.Fatalf("Token %q normalized to invalid UTF-8 %q", core, token.Normalized)
+			}
+			last = token.End
+		}
+
+		// Masking only ever replaces words, so checking the masked text
+		// again must leave it unchanged.
+		masked := filter.Check(text).Text
+		if again := filter.Check(masked).Text; again != masked {
+			t.Fatalf("Masking %q is not idempotent: %q then %q", text, masked, again)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// leet maps the characters commonly swapped in for letters back to the
+// letters they stand for.
+var leet = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'@': 'a',
+	'$': 's',
+}
+
+// Normalize reduces a word to the form rules are matched against: NFKC
+// so that look-alike forms such as fullwidth letters become plain ones,
+// case folded, stripped of accents, with leetspeak undone and every
+// remaining punctuation, symbol or invisible character removed. Both
+// "K3rfuffle" and "ｋｅｒｆｕｆｆｌｅ" normalize to "kerfuffle".
+func Normalize(word string) string {
+	// A Caser keeps state, so each call gets its own.
+	folded := cases.Fold().String(norm.NFKC.String(word))
+
+	b := strings.Builder{}
+	for _, r := range norm.NFD.String(folded) {
+		if l, ok := leet[r]; ok {
+			r = l
+		}
+		if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r) ||
+			unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r) || r == utf8.RuneError {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// Token is one word of a text. Start and End are the byte offsets of the
+// word with any punctuation around it trimmed off, which is the part
+// that gets masked.
+type Token struct {
+	Start      int
+	End        int
+	Normalized string
+}
+
+// Tokenize splits text into whitespace-separated words and normalizes
+// each of them. Words that normalize to nothing, such as "--", are
+// skipped.
+func Tokenize(text string) []Token {
+	tokens := []Token{}
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		start := i
+		for i < len(text) {
+			r, size = utf8.DecodeRuneInString(text[i:])
+			if unicode.IsSpace(r) {
+				break
+			}
+			i += size
+		}
+
+		start, end := trimEdges(text, start, i)
+		if start == end {
+			continue
+		}
+		normalized := Normalize(text[start:end])
+		if normalized == "" {
+			continue
+		}
+		tokens = append(tokens, Token{Start: start, End: end, Normalized: normalized})
+	}
+
+	return tokens
+}
+
+// trimEdges narrows text[start:end] past punctuation and symbols on
+// either side, keeping the ones that stand in for letters.
+func trimEdges(text string, start, end int) (int, int) {
+	for start < end {
+		r, size := utf8.DecodeRuneInString(text[start:end])
+		if !isEdge(r) {
+			break
+		}
+		start += size
+	}
+	for start < end {
+		r, size := utf8.DecodeLastRuneInString(text[start:end])
+		if !isEdge(r) {
+			break
+		}
+		end -= size
+	}
+	return start, end
+}
+
+func isEdge(r rune) bool {
+	if _, ok := leet[r]; ok {
+		return false
+	}
+	return unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.Is(unicode.Cf, r)
+}
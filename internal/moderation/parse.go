@@ -0,0 +1,47 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseRules reads rules in the format of a rules file: one rule per
+// line, an action followed by either a word or a regular expression
+// between slashes. Blank lines and lines starting with # are ignored.
+//
+//	# masked, as "****"
+//	mask kerfuffle
+//	reject /^f+o+r+n+a+x+$/
+//	review sharbert
+func ParseRules(r io.Reader) ([]Rule, error) {
+	rules := []Rule{}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		action, value, ok := strings.Cut(line, " ")
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: line %d: expected an action and a word or /pattern/", ErrInvalidRule, n)
+		}
+
+		rule := Rule{Action: Action(action)}
+		if len(value) >= 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+			rule.Pattern = value[1 : len(value)-1]
+		} else {
+			rule.Word = value
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
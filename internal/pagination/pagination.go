@@ -0,0 +1,79 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidLimit  = errors.New("invalid limit")
+)
+
+// Cursor marks the last row of a page. Lists are ordered by
+// (created_at, id), so the next page seeks past this pair instead of
+// using an OFFSET that shifts when new rows are inserted. Ranked search
+// results are ordered by (rank, id) and use Rank instead of CreatedAt.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	Rank      float64   `json:"r,omitempty"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// End sorts after every row. Descending lists start from it when the
+// client has not sent a cursor yet.
+var End = Cursor{
+	CreatedAt: time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC),
+	Rank:      math.MaxFloat32,
+	ID:        uuid.Max,
+}
+
+// Encode turns a cursor into the opaque string handed to clients.
+func Encode(c Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses a cursor produced by Encode.
+func Decode(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	c := Cursor{}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if c.ID == uuid.Nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// ParseLimit reads a page size from a query parameter, falling back to
+// DefaultLimit when it is empty and capping it at MaxLimit.
+func ParseLimit(s string) (int, error) {
+	if s == "" {
+		return DefaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 {
+		return 0, ErrInvalidLimit
+	}
+
+	return min(limit, MaxLimit), nil
+}
@@ -0,0 +1,70 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEncodeAndDecode(t *testing.T) {
+	c := Cursor{
+		CreatedAt: time.Date(2025, 4, 1, 12, 30, 0, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+
+	decoded, err := Decode(Encode(c))
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if !decoded.CreatedAt.Equal(c.CreatedAt) || decoded.ID != c.ID {
+		t.Errorf("Expected %+v, got %+v", c, decoded)
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	for _, s := range []string{"", "not-base64!", Encode(Cursor{}), "eyJ0IjoxfQ"} {
+		if _, err := Decode(s); err != ErrInvalidCursor {
+			t.Errorf("Decode(%q): expected ErrInvalidCursor, got %v", s, err)
+		}
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"", DefaultLimit, false},
+		{"10", 10, false},
+		{"1000", MaxLimit, false},
+		{"0", 0, true},
+		{"-5", 0, true},
+		{"ten", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLimit(%q): unexpected error %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q): expected %d, got %d", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestEncodeAndDecode_Ranked(t *testing.T) {
+	c := Cursor{Rank: float64(float32(0.0607927)), ID: uuid.New()}
+
+	decoded, err := Decode(Encode(c))
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+
+	if float32(decoded.Rank) != float32(c.Rank) || decoded.ID != c.ID {
+		t.Errorf("Expected %+v, got %+v", c, decoded)
+	}
+}
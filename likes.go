@@ -0,0 +1,162 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type userSummaryJSON struct {
+	ID     uuid.UUID `json:"id"`
+	Handle *string   `json:"handle"`
+}
+
+func (cfg *apiConfig) handleLikeChirp(w http.ResponseWriter, req *http.Request) {
+	userID, chirp, ok := cfg.authorizeChirpAction(w, req)
+	if !ok {
+		return
+	}
+
+	liked, err := cfg.db.CreateChirpLike(req.Context(), database.CreateChirpLikeParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		log.Printf("Error liking chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	if liked > 0 {
+		cfg.notify(req.Context(), likeNotification(userID, chirp))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleUnlikeChirp(w http.ResponseWriter, req *http.Request) {
+	userID, chirp, ok := cfg.authorizeChirpAction(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.DeleteChirpLike(req.Context(), database.DeleteChirpLikeParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		log.Printf("Error unliking chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizeChirpAction authenticates the caller and fetches the chirp in
+// the path for them to act on, such as by liking or bookmarking it.
+// Deleted chirps and chirps by users blocked either way are reported as
+// not found. It writes the error response itself when ok is false.
+func (cfg *apiConfig) authorizeChirpAction(w http.ResponseWriter, req *http.Request) (userID uuid.UUID, chirp database.Chirp, ok bool) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return uuid.Nil, database.Chirp{}, false
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "chirpID must be a valid UUID",
+		})
+		return uuid.Nil, database.Chirp{}, false
+	}
+
+	chirp, err = cfg.db.GetChirpByID(req.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid || cfg.blockedEitherWay(req.Context(), userID, chirp.UserID) {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return uuid.Nil, database.Chirp{}, false
+	}
+
+	return userID, chirp, true
+}
+
+// handleGetChirpLikes lists who liked a chirp, most recent first, leaving
+// out users hidden from the viewer.
+func (cfg *apiConfig) handleGetChirpLikes(w http.ResponseWriter, req *http.Request) {
+	type likerJSON struct {
+		userSummaryJSON
+		LikedAt time.Time `json:"liked_at"`
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "chirpID must be a valid UUID",
+		})
+		return
+	}
+
+	// The same chirps are found here as when liking them.
+	viewerID := cfg.viewerID(req)
+	chirp, err := cfg.db.GetChirpByID(req.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid || cfg.blockedEitherWay(req.Context(), viewerID, chirp.UserID) {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	rows, err := cfg.db.ListChirpLikers(req.Context(), database.ListChirpLikersParams{
+		ChirpID:         chirpID,
+		ViewerID:        viewerID,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		MaxRows:         int32(limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error fetching chirp likes: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.LikedAt, ID: last.ID})
+	}
+
+	likers := []likerJSON{}
+	for _, row := range rows {
+		likers = append(likers, likerJSON{
+			userSummaryJSON: userSummaryJSON{
+				ID:     row.ID,
+				Handle: nullStringPtr(row.Handle),
+			},
+			LikedAt: row.LikedAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, likers)
+}
@@ -53,6 +53,13 @@ func (cfg *apiConfig) handleLogin(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if user.BannedAt.Valid {
+		writeJSON(w, http.StatusForbidden, errorJSON{
+			Error: "This account has been banned",
+		})
+		return
+	}
+
 	token, err := auth.MakeJWT(user.ID, cfg.jwtToken)
 	if err != nil {
 		log.Printf("Error while creating token: %s", err)
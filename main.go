@@ -1,22 +1,49 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"sync/atomic"
+	"time"
 
+	"github.com/flames31/Chirpy/internal/blobstore"
+	"github.com/flames31/Chirpy/internal/broadcast"
 	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/media"
+	"github.com/flames31/Chirpy/internal/moderation"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
-	db             *database.Queries
-	fileServerHits atomic.Int32
-	jwtToken       string
-	polkaAPIKey    string
+	db                *database.Queries
+	sqlDB             *sql.DB
+	dbURL             string
+	timeline          timelineSource
+	notifier          notifier
+	chirpStream       *broadcast.Hub[streamEvent]
+	blobs             blobstore.BlobStore
+	mediaJobs         chan uuid.UUID
+	maxMediaPixels    int
+	maxChirpLength    int
+	redMaxChirpLength int
+	fileServerHits    atomic.Int32
+	jwtToken          string
+	polkaAPIKey       string
+	adminIDs          []uuid.UUID
+	editWindow        time.Duration
+	redEditWindow     time.Duration
+	chirpRetention    time.Duration
+	// filter holds the moderation rules in force. It is swapped out
+	// whole when the rules are reloaded.
+	filter              atomic.Pointer[moderation.Filter]
+	moderationRulesFile string
 }
 
 func main() {
@@ -31,25 +58,118 @@ func main() {
 	}
 	dbQueries := database.New(db)
 
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+	blobs, err := blobstore.NewLocal(mediaDir, "/media")
+	if err != nil {
+		log.Fatalf("Issue with opening media directory: %v", err)
+	}
+
 	mux := http.NewServeMux()
 	cfg := apiConfig{
-		fileServerHits: atomic.Int32{},
-		db:             dbQueries,
-		jwtToken:       os.Getenv("JWT_TOKEN"),
-		polkaAPIKey:    os.Getenv("POLKA_KEY"),
+		fileServerHits:      atomic.Int32{},
+		db:                  dbQueries,
+		sqlDB:               db,
+		dbURL:               dbURL,
+		timeline:            queryTimeline{db: dbQueries},
+		notifier:            queryNotifier{db: dbQueries},
+		chirpStream:         broadcast.New[streamEvent](),
+		blobs:               blobs,
+		mediaJobs:           make(chan uuid.UUID, mediaQueueSize),
+		maxMediaPixels:      intFromEnv("MEDIA_MAX_PIXELS", media.DefaultMaxPixels),
+		maxChirpLength:      intFromEnv("CHIRP_MAX_LENGTH", 140),
+		redMaxChirpLength:   intFromEnv("CHIRP_RED_MAX_LENGTH", 280),
+		jwtToken:            os.Getenv("JWT_TOKEN"),
+		polkaAPIKey:         os.Getenv("POLKA_KEY"),
+		adminIDs:            uuidsFromEnv("ADMIN_USER_IDS"),
+		editWindow:          durationFromEnv("CHIRP_EDIT_WINDOW", 15*time.Minute),
+		redEditWindow:       durationFromEnv("CHIRP_RED_EDIT_WINDOW", time.Hour),
+		chirpRetention:      durationFromEnv("CHIRP_RETENTION", 30*24*time.Hour),
+		moderationRulesFile: os.Getenv("MODERATION_RULES_FILE"),
 	}
+	cfg.promoteAdmins(context.Background())
+	cfg.initModeration(context.Background())
+	go cfg.runModerationReloader(context.Background())
+	go cfg.runChirpPurger(context.Background())
+	go cfg.runDraftPublisher(context.Background())
+	go cfg.runChirpStream(context.Background())
+	go cfg.runMediaProcessor(context.Background(), intFromEnv("MEDIA_WORKERS", 2))
+
 	mux.Handle("/app/", cfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filePathRoot)))))
+	mux.Handle("GET /media/", noDirListing(http.StripPrefix("/media", http.FileServer(http.Dir(mediaDir)))))
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
 	mux.HandleFunc("GET /admin/metrics", cfg.handleMetrics)
 	mux.HandleFunc("GET /api/chirps", cfg.handleGetAllChirps)
+	mux.HandleFunc("GET /api/chirps/search", cfg.handleSearchChirps)
+	mux.HandleFunc("GET /api/stream/chirps", cfg.handleStreamChirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", cfg.handleGetChirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", cfg.handleUpdateChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/revisions", cfg.handleGetChirpRevisions)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/thread", cfg.handleGetThread)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/likes", cfg.handleGetChirpLikes)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/likes", cfg.handleLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/likes", cfg.handleUnlikeChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/poll/votes", cfg.handleVotePoll)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/reports", cfg.handleReportChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", cfg.handleBookmarkChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", cfg.handleUnbookmarkChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", cfg.handlePinChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", cfg.handleUnpinChirp)
+	mux.HandleFunc("GET /api/hashtags/trending", cfg.handleGetTrendingHashtags)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.handleGetHashtagChirps)
 	mux.HandleFunc("POST /admin/reset", cfg.handleReset)
+	mux.HandleFunc("POST /admin/chirps/{chirpID}/restore", cfg.handleRestoreChirp)
+	mux.HandleFunc("DELETE /admin/chirps/{chirpID}", cfg.handlePurgeChirp)
+	mux.HandleFunc("POST /admin/moderation/reload", cfg.handleReloadModeration)
+	mux.HandleFunc("GET /admin/moderation/queue", cfg.handleGetModerationQueue)
+	mux.HandleFunc("POST /admin/moderation/chirps/{chirpID}/actions", cfg.handleModerateChirp)
+	mux.HandleFunc("GET /admin/moderation/actions", cfg.handleGetModerationActions)
+	mux.HandleFunc("PUT /admin/users/{userID}/role", cfg.handleSetUserRole)
 	mux.HandleFunc("POST /api/chirps", cfg.handleCreateChirp)
+	mux.HandleFunc("POST /api/media", cfg.handleUploadMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}", cfg.handleGetMedia)
+	mux.HandleFunc("GET /api/drafts", cfg.handleGetDrafts)
+	mux.HandleFunc("POST /api/drafts", cfg.handleCreateDraft)
+	mux.HandleFunc("GET /api/drafts/{draftID}", cfg.handleGetDraft)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", cfg.handleUpdateDraft)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", cfg.handleDeleteDraft)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", cfg.handlePublishDraft)
 	mux.HandleFunc("POST /api/users", cfg.handlerCreateUser)
 	mux.HandleFunc("POST /api/login", cfg.handleLogin)
 	mux.HandleFunc("POST /api/refresh", cfg.handleRefresh)
 	mux.HandleFunc("POST /api/revoke", cfg.handleRevoke)
 	mux.HandleFunc("PUT /api/users", cfg.handleUpdateCredentials)
+	mux.HandleFunc("PATCH /api/users/me", cfg.handleUpdateProfile)
+	mux.HandleFunc("GET /api/users/me/mentions", cfg.handleGetMentions)
+	mux.HandleFunc("GET /api/users/me/blocks", cfg.handleGetBlocks)
+	mux.HandleFunc("POST /api/users/me/blocks", cfg.handleBlockUser)
+	mux.HandleFunc("DELETE /api/users/me/blocks/{userID}", cfg.handleUnblockUser)
+	mux.HandleFunc("GET /api/users/me/mutes", cfg.handleGetMutes)
+	mux.HandleFunc("GET /api/users/me/bookmarks", cfg.handleGetBookmarks)
+	mux.HandleFunc("GET /api/users/me/collections", cfg.handleGetCollections)
+	mux.HandleFunc("POST /api/users/me/collections", cfg.handleCreateCollection)
+	mux.HandleFunc("PUT /api/users/me/collections/order", cfg.handleReorderCollections)
+	mux.HandleFunc("PATCH /api/users/me/collections/{collectionID}", cfg.handleRenameCollection)
+	mux.HandleFunc("DELETE /api/users/me/collections/{collectionID}", cfg.handleDeleteCollection)
+	mux.HandleFunc("POST /api/users/me/mutes", cfg.handleMuteUser)
+	mux.HandleFunc("DELETE /api/users/me/mutes/{userID}", cfg.handleUnmuteUser)
+	mux.HandleFunc("GET /api/users/{handleOrID}", cfg.handleGetProfile)
+	mux.HandleFunc("POST /api/users/{userID}/follow", cfg.handleFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", cfg.handleUnfollowUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", cfg.handleGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", cfg.handleGetFollowing)
+	mux.HandleFunc("GET /api/timeline", cfg.handleGetTimeline)
+	mux.HandleFunc("GET /api/notifications", cfg.handleGetNotifications)
+	mux.HandleFunc("POST /api/notifications/read", cfg.handleMarkNotificationsRead)
+	mux.HandleFunc("GET /api/notifications/preferences", cfg.handleGetNotificationPreferences)
+	mux.HandleFunc("PUT /api/notifications/preferences", cfg.handleUpdateNotificationPreferences)
+	mux.HandleFunc("GET /api/conversations", cfg.handleGetConversations)
+	mux.HandleFunc("POST /api/conversations", cfg.handleCreateConversation)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", cfg.handleGetMessages)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", cfg.handleSendMessage)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", cfg.handleMarkConversationRead)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", cfg.handleDeleteChirp)
 	mux.HandleFunc("POST /api/polka/webhooks", cfg.handlerUpdateChirpyRed)
 	server := http.Server{
@@ -62,3 +182,54 @@ func main() {
 	}
 
 }
+
+// durationFromEnv parses an environment variable such as "15m", falling
+// back to def when it is unset or invalid.
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %s", name, value, def)
+		return def
+	}
+	return d
+}
+
+// intFromEnv parses a positive integer environment variable, falling back
+// to def when it is unset or invalid.
+func intFromEnv(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid %s %q, using %d", name, value, def)
+		return def
+	}
+	return n
+}
+
+// uuidsFromEnv parses a comma-separated list of UUIDs from an environment
+// variable, skipping empty and invalid entries.
+func uuidsFromEnv(name string) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, err := uuid.Parse(item)
+		if err != nil {
+			log.Printf("Invalid %s entry %q, skipping it", name, item)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/media"
+	"github.com/google/uuid"
+)
+
+const (
+	maxMediaBytes = 5 << 20
+	maxChirpMedia = 4
+	// multipartOverhead leaves room for the boundaries and part headers
+	// around the file itself.
+	multipartOverhead = 64 << 10
+)
+
+// mediaJSON is an upload. URL serves the sanitized original; Variants
+// stays empty while Processing is true.
+type mediaJSON struct {
+	ID          uuid.UUID          `json:"id"`
+	URL         string             `json:"url"`
+	ContentType string             `json:"content_type"`
+	Width       int32              `json:"width"`
+	Height      int32              `json:"height"`
+	Processing  bool               `json:"processing"`
+	Variants    []mediaVariantJSON `json:"variants"`
+}
+
+func (cfg *apiConfig) newMediaJSON(m database.Media, variants []mediaVariantJSON) mediaJSON {
+	if variants == nil {
+		variants = []mediaVariantJSON{}
+	}
+	return mediaJSON{
+		ID:          m.ID,
+		URL:         cfg.blobs.URL(m.BlobKey),
+		ContentType: m.ContentType,
+		Width:       m.Width,
+		Height:      m.Height,
+		Processing:  !m.ProcessedAt.Valid,
+		Variants:    variants,
+	}
+}
+
+func (cfg *apiConfig) handleUploadMedia(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	tooLarge := fmt.Sprintf("Media must be at most %d MB", maxMediaBytes>>20)
+
+	req.Body = http.MaxBytesReader(w, req.Body, maxMediaBytes+multipartOverhead)
+	file, _, err := req.FormFile("file")
+	if maxBytesErr := (*http.MaxBytesError)(nil); errors.As(err, &maxBytesErr) {
+		writeJSON(w, http.StatusRequestEntityTooLarge, errorJSON{
+			Error: tooLarge,
+		})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Expected a multipart form with a file field",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxMediaBytes+1))
+	if err != nil {
+		log.Printf("Error reading upload: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	if len(data) > maxMediaBytes {
+		writeJSON(w, http.StatusRequestEntityTooLarge, errorJSON{
+			Error: tooLarge,
+		})
+		return
+	}
+
+	img, err := media.Sanitize(data, cfg.maxMediaPixels)
+	switch {
+	case errors.Is(err, media.ErrUnsupportedType):
+		writeJSON(w, http.StatusUnsupportedMediaType, errorJSON{
+			Error: "Only JPEG, PNG and GIF images are supported",
+		})
+		return
+	case errors.Is(err, media.ErrInvalidImage):
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Image could not be decoded",
+		})
+		return
+	case errors.Is(err, media.ErrTooManyPixels):
+		writeJSON(w, http.StatusRequestEntityTooLarge, errorJSON{
+			Error: "Image dimensions are too large",
+		})
+		return
+	case err != nil:
+		log.Printf("Error sanitizing upload: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	key := uuid.NewString() + img.Ext
+	if err := cfg.blobs.Put(req.Context(), key, bytes.NewReader(img.Data)); err != nil {
+		log.Printf("Error storing media: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	m, err := cfg.db.CreateMedia(req.Context(), database.CreateMediaParams{
+		UserID:      userID,
+		BlobKey:     key,
+		ContentType: img.ContentType,
+		Width:       int32(img.Width),
+		Height:      int32(img.Height),
+		SizeBytes:   int64(len(img.Data)),
+	})
+	if err != nil {
+		log.Printf("Error creating media: %s", err)
+		if err := cfg.blobs.Delete(context.Background(), key); err != nil {
+			log.Printf("Error deleting orphaned media %s: %s", key, err)
+		}
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	cfg.enqueueMedia(m.ID)
+
+	writeJSON(w, http.StatusCreated, cfg.newMediaJSON(m, nil))
+}
+
+func (cfg *apiConfig) handleGetMedia(w http.ResponseWriter, req *http.Request) {
+	mediaID, err := uuid.Parse(req.PathValue("mediaID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "mediaID must be a valid UUID",
+		})
+		return
+	}
+
+	m, err := cfg.db.GetMediaByID(req.Context(), mediaID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Media not found",
+		})
+		return
+	}
+
+	variants, err := cfg.loadMediaVariants(req.Context(), []uuid.UUID{m.ID})
+	if err != nil {
+		log.Printf("Error loading media variants: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cfg.newMediaJSON(m, variants[m.ID]))
+}
+
+// resolveMedia checks the media a new chirp by userID wants to attach:
+// at most maxChirpMedia of them, no repeats, and only the user's own
+// uploads. On failure it returns the status and message to send back.
+func (cfg *apiConfig) resolveMedia(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, int, string) {
+	if len(ids) > maxChirpMedia {
+		return nil, http.StatusBadRequest, fmt.Sprintf("A chirp can have at most %d media attachments", maxChirpMedia)
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return nil, http.StatusBadRequest, "media_ids must not repeat"
+		}
+		seen[id] = true
+	}
+
+	mediaIDs := []uuid.UUID{}
+	if len(ids) == 0 {
+		return mediaIDs, 0, ""
+	}
+
+	rows, err := cfg.db.GetMediaByIDs(ctx, ids)
+	if err != nil {
+		log.Printf("Error fetching media: %s", err)
+		return nil, http.StatusInternalServerError, "Something went wrong"
+	}
+	if len(rows) != len(ids) || slices.ContainsFunc(rows, func(m database.Media) bool { return m.UserID != userID }) {
+		return nil, http.StatusBadRequest, "media_ids must reference your own uploads"
+	}
+
+	return append(mediaIDs, ids...), 0, ""
+}
+
+// linkChirpMedia attaches media to a chirp in the order given.
+func linkChirpMedia(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID, mediaIDs []uuid.UUID) error {
+	for i, mediaID := range mediaIDs {
+		err := qtx.CreateChirpMedia(ctx, database.CreateChirpMediaParams{
+			ChirpID:  chirpID,
+			MediaID:  mediaID,
+			Position: int16(i),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// noDirListing keeps a file server from listing directories, so media
+// can only be fetched by the URLs handed out for it.
+func noDirListing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/") {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/chirptext"
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const maxMessageLength = 1000
+
+type messageJSON struct {
+	ID             uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Body           string    `json:"body"`
+}
+
+func newMessageJSON(message database.Message) messageJSON {
+	return messageJSON{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		CreatedAt:      message.CreatedAt,
+		Body:           message.Body,
+	}
+}
+
+// handleSendMessage posts a message to a conversation. The body goes
+// through the same moderation rules as chirps, except that messages are
+// private, so matches of review rules raise no flag.
+//
+// In a one-to-one conversation a block in either direction stops new
+// messages. In a group, users who have blocked each other can both still
+// post, but neither sees the other's messages.
+func (cfg *apiConfig) handleSendMessage(w http.ResponseWriter, req *http.Request) {
+	type incoming struct {
+		Body string `json:"body"`
+	}
+
+	userID, conversation, ok := cfg.authorizeConversation(w, req)
+	if !ok {
+		return
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+
+	body := strings.TrimSpace(incomingJSON.Body)
+	if body == "" {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "A message needs a body",
+		})
+		return
+	}
+	if n := chirptext.Length(body); n > maxMessageLength {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: fmt.Sprintf("Message is too long: %d characters used, %d allowed", n, maxMessageLength),
+		})
+		return
+	}
+	checked := cfg.moderate(body)
+	if checked.Rejected {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Message contains language that is not allowed",
+		})
+		return
+	}
+
+	if conversation.DirectKey.Valid {
+		members, err := cfg.db.ListConversationMembers(req.Context(), []uuid.UUID{conversation.ID})
+		if err != nil {
+			log.Printf("Error fetching conversation members: %s", err)
+			writeJSON(w, http.StatusInternalServerError, errorJSON{
+				Error: "Something went wrong",
+			})
+			return
+		}
+		for _, member := range members {
+			if member.ID != userID && cfg.blockedEitherWay(req.Context(), userID, member.ID) {
+				writeJSON(w, http.StatusForbidden, errorJSON{
+					Error: "You cannot message this user",
+				})
+				return
+			}
+		}
+	}
+
+	message, err := cfg.sendMessage(req.Context(), database.CreateMessageParams{
+		ConversationID: conversation.ID,
+		SenderID:       userID,
+		Body:           checked.Text,
+	})
+	if err != nil {
+		log.Printf("Error sending message: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newMessageJSON(message))
+}
+
+// sendMessage numbers and stores a message, and moves its conversation to
+// the top of its members' lists, in one transaction.
+func (cfg *apiConfig) sendMessage(ctx context.Context, params database.CreateMessageParams) (database.Message, error) {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Message{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	params.Seq, err = qtx.AdvanceConversation(ctx, params.ConversationID)
+	if err != nil {
+		return database.Message{}, err
+	}
+
+	message, err := qtx.CreateMessage(ctx, params)
+	if err != nil {
+		return database.Message{}, err
+	}
+
+	return message, tx.Commit()
+}
+
+// handleGetMessages lists a conversation's messages, newest first,
+// leaving out messages from users blocked either way by the caller.
+func (cfg *apiConfig) handleGetMessages(w http.ResponseWriter, req *http.Request) {
+	userID, conversation, ok := cfg.authorizeConversation(w, req)
+	if !ok {
+		return
+	}
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	rows, err := cfg.db.ListMessages(req.Context(), database.ListMessagesParams{
+		ConversationID:  conversation.ID,
+		ViewerID:        userID,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		MaxRows:         int32(limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error fetching messages: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	messages := []messageJSON{}
+	for _, row := range rows {
+		messages = append(messages, newMessageJSON(row))
+	}
+	writeJSON(w, http.StatusOK, messages)
+}
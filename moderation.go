@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+const moderationReloadInterval = 5 * time.Minute
+
+// defaultModerationRules are in force until rules have been loaded, and
+// stay in force if the configured rules cannot be loaded at startup.
+var defaultModerationRules = []moderation.Rule{
+	{Word: "kerfuffle", Action: moderation.ActionMask},
+	{Word: "sharbert", Action: moderation.ActionMask},
+	{Word: "fornax", Action: moderation.ActionMask},
+}
+
+// initModeration installs the default rules, then replaces them with the
+// configured ones if those load.
+func (cfg *apiConfig) initModeration(ctx context.Context) {
+	filter, err := moderation.NewFilter(defaultModerationRules)
+	if err != nil {
+		log.Fatalf("Invalid default moderation rules: %v", err)
+	}
+	cfg.filter.Store(filter)
+
+	n, err := cfg.loadModerationRules(ctx)
+	if err != nil {
+		log.Printf("Error loading moderation rules, using defaults: %s", err)
+		return
+	}
+	log.Printf("Loaded %d moderation rules", n)
+}
+
+// loadModerationRules compiles the rules in cfg.moderationRulesFile, or
+// in the moderation_rules table when no file is configured, and swaps
+// them in. The current rules stay in force if the new ones are invalid.
+func (cfg *apiConfig) loadModerationRules(ctx context.Context) (int, error) {
+	var rules []moderation.Rule
+	if cfg.moderationRulesFile != "" {
+		f, err := os.Open(cfg.moderationRulesFile)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		rules, err = moderation.ParseRules(f)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		rows, err := cfg.db.ListModerationRules(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, row := range rows {
+			rule := moderation.Rule{Action: moderation.Action(row.Action)}
+			if row.Kind == "pattern" {
+				rule.Pattern = row.Value
+			} else {
+				rule.Word = row.Value
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	filter, err := moderation.NewFilter(rules)
+	if err != nil {
+		return 0, err
+	}
+	cfg.filter.Store(filter)
+	return filter.Len(), nil
+}
+
+// runModerationReloader reloads the rules once per
+// moderationReloadInterval until ctx is cancelled, so every instance
+// picks up changes made through another one.
+func (cfg *apiConfig) runModerationReloader(ctx context.Context) {
+	ticker := time.NewTicker(moderationReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := cfg.loadModerationRules(ctx); err != nil {
+			log.Printf("Error reloading moderation rules: %s", err)
+		}
+	}
+}
+
+func (cfg *apiConfig) handleReloadModeration(w http.ResponseWriter, req *http.Request) {
+	type response struct {
+		Rules int `json:"rules"`
+	}
+
+	if _, ok := cfg.requireAdmin(w, req); !ok {
+		return
+	}
+
+	n, err := cfg.loadModerationRules(req.Context())
+	if err != nil {
+		log.Printf("Error reloading moderation rules: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Could not reload moderation rules: " + err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response{Rules: n})
+}
+
+// moderate runs text through the rules currently in force.
+func (cfg *apiConfig) moderate(text string) moderation.Result {
+	return cfg.filter.Load().Check(text)
+}
+
+// flagChirp queues a chirp for a moderator when any of matched asks for
+// review, inside the caller's transaction.
+func flagChirp(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID, matched []moderation.Rule) error {
+	reasons := []string{}
+	for _, rule := range matched {
+		if rule.Action == moderation.ActionReview {
+			reasons = append(reasons, rule.String())
+		}
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	return qtx.CreateChirpFlag(ctx, database.CreateChirpFlagParams{
+		ChirpID: chirpID,
+		Source:  "filter",
+		Reason:  strings.Join(reasons, ", "),
+	})
+}
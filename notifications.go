@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	notificationLike    = "like"
+	notificationReply   = "reply"
+	notificationFollow  = "follow"
+	notificationMention = "mention"
+
+	// maxNotificationActors is how many of a group's most recent actors
+	// are listed; actor_count has the full number, up to
+	// maxNotificationGroupEvents.
+	maxNotificationActors      = 3
+	maxNotificationGroupEvents = 1000
+
+	// Only notifications from the last notificationWindow are listed and
+	// counted, so listing does not slow down as a user's history grows.
+	notificationWindow = 90 * 24 * time.Hour
+)
+
+var notificationTypes = []string{notificationLike, notificationReply, notificationFollow, notificationMention}
+
+// notificationEvent is something that happened to RecipientID because of
+// ActorID. Events with the same GroupKey are listed as one entry.
+type notificationEvent struct {
+	Type        string
+	RecipientID uuid.UUID
+	ActorID     uuid.UUID
+	ChirpID     uuid.NullUUID
+	GroupKey    string
+}
+
+// notifier records notification events. Handlers only describe what
+// happened; opt-outs and duplicate events are the notifier's concern.
+// queryNotifier writes each event to the database as it is reported.
+type notifier interface {
+	Notify(ctx context.Context, e notificationEvent) error
+}
+
+type queryNotifier struct {
+	db *database.Queries
+}
+
+func (n queryNotifier) Notify(ctx context.Context, e notificationEvent) error {
+	if e.RecipientID == e.ActorID {
+		return nil
+	}
+	return n.db.CreateNotification(ctx, database.CreateNotificationParams{
+		RecipientID: e.RecipientID,
+		ActorID:     e.ActorID,
+		Type:        e.Type,
+		ChirpID:     e.ChirpID,
+		GroupKey:    e.GroupKey,
+	})
+}
+
+// Likes of a chirp are grouped together, as are all new followers. Each
+// reply and mention is an entry of its own.
+
+func likeNotification(userID uuid.UUID, chirp database.Chirp) notificationEvent {
+	return notificationEvent{
+		Type:        notificationLike,
+		RecipientID: chirp.UserID,
+		ActorID:     userID,
+		ChirpID:     uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		GroupKey:    notificationLike + ":" + chirp.ID.String(),
+	}
+}
+
+func followNotification(followerID, followeeID uuid.UUID) notificationEvent {
+	return notificationEvent{
+		Type:        notificationFollow,
+		RecipientID: followeeID,
+		ActorID:     followerID,
+		GroupKey:    notificationFollow,
+	}
+}
+
+func replyNotification(reply, parent database.Chirp) notificationEvent {
+	return notificationEvent{
+		Type:        notificationReply,
+		RecipientID: parent.UserID,
+		ActorID:     reply.UserID,
+		ChirpID:     uuid.NullUUID{UUID: reply.ID, Valid: true},
+		GroupKey:    notificationReply + ":" + reply.ID.String(),
+	}
+}
+
+func mentionNotification(chirp database.Chirp, userID uuid.UUID) notificationEvent {
+	return notificationEvent{
+		Type:        notificationMention,
+		RecipientID: userID,
+		ActorID:     chirp.UserID,
+		ChirpID:     uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		GroupKey:    notificationMention + ":" + chirp.ID.String(),
+	}
+}
+
+// notify reports events to the notifier. Notifications are a side effect
+// of whatever the caller already did, so failures are logged rather than
+// returned.
+func (cfg *apiConfig) notify(ctx context.Context, events ...notificationEvent) {
+	for _, e := range events {
+		if err := cfg.notifier.Notify(ctx, e); err != nil {
+			log.Printf("Error recording %s notification: %s", e.Type, err)
+		}
+	}
+}
+
+// notifyChirp notifies the author of the chirp that chirp replies to and
+// the users it mentions. It is called again after an edit; users already
+// notified about the chirp are not notified twice.
+func (cfg *apiConfig) notifyChirp(ctx context.Context, chirp database.Chirp) {
+	events := []notificationEvent{}
+
+	parentAuthorID := uuid.Nil
+	if chirp.InReplyTo.Valid {
+		parent, err := cfg.db.GetChirpByID(ctx, chirp.InReplyTo.UUID)
+		if err != nil {
+			log.Printf("Error fetching replied-to chirp: %s", err)
+		} else if !parent.DeletedAt.Valid {
+			parentAuthorID = parent.UserID
+			events = append(events, replyNotification(chirp, parent))
+		}
+	}
+
+	mentioned, err := cfg.db.ListChirpMentionUserIDs(ctx, chirp.ID)
+	if err != nil {
+		log.Printf("Error fetching chirp mentions: %s", err)
+	}
+	for _, userID := range mentioned {
+		// The reply already tells the parent's author about it.
+		if userID != parentAuthorID {
+			events = append(events, mentionNotification(chirp, userID))
+		}
+	}
+
+	cfg.notify(ctx, events...)
+}
+
+type notificationJSON struct {
+	ID         uuid.UUID         `json:"id"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Type       string            `json:"type"`
+	ChirpID    *uuid.UUID        `json:"chirp_id"`
+	Actors     []userSummaryJSON `json:"actors"`
+	ActorCount int64             `json:"actor_count"`
+	Read       bool              `json:"read"`
+}
+
+// handleGetNotifications lists the caller's notifications, most recent
+// first, with events of the same group collapsed into one entry.
+// unread_count is the number of entries with unread events across all
+// pages. Only recent notifications are included; see notificationWindow.
+func (cfg *apiConfig) handleGetNotifications(w http.ResponseWriter, req *http.Request) {
+	type outgoing struct {
+		UnreadCount   int64              `json:"unread_count"`
+		Notifications []notificationJSON `json:"notifications"`
+	}
+
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	since := time.Now().Add(-notificationWindow)
+	rows, err := cfg.db.ListNotificationGroups(req.Context(), database.ListNotificationGroupsParams{
+		UserID:          userID,
+		Since:           since,
+		MaxGroupEvents:  maxNotificationGroupEvents,
+		MaxActors:       maxNotificationActors,
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		MaxRows:         int32(limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error fetching notifications: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	unread, err := cfg.db.CountUnreadNotificationGroups(req.Context(), database.CountUnreadNotificationGroupsParams{
+		UserID: userID,
+		Since:  since,
+	})
+	if err != nil {
+		log.Printf("Error counting notifications: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	actorIDs := []uuid.UUID{}
+	for _, row := range rows {
+		for _, id := range row.ActorIds {
+			if !slices.Contains(actorIDs, id) {
+				actorIDs = append(actorIDs, id)
+			}
+		}
+	}
+	actors, err := cfg.db.GetUsersByIDs(req.Context(), actorIDs)
+	if err != nil {
+		log.Printf("Error fetching notification actors: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	handles := map[uuid.UUID]*string{}
+	for _, actor := range actors {
+		handles[actor.ID] = nullStringPtr(actor.Handle)
+	}
+
+	notificationsJSON := []notificationJSON{}
+	for _, row := range rows {
+		n := notificationJSON{
+			ID:         row.ID,
+			CreatedAt:  row.CreatedAt,
+			Type:       row.Type,
+			Actors:     []userSummaryJSON{},
+			ActorCount: row.ActorCount,
+			Read:       !row.Unread,
+		}
+		if row.ChirpID.Valid {
+			n.ChirpID = &row.ChirpID.UUID
+		}
+		for _, id := range row.ActorIds {
+			n.Actors = append(n.Actors, userSummaryJSON{
+				ID:     id,
+				Handle: handles[id],
+			})
+		}
+		notificationsJSON = append(notificationsJSON, n)
+	}
+
+	writeJSON(w, http.StatusOK, outgoing{
+		UnreadCount:   unread,
+		Notifications: notificationsJSON,
+	})
+}
+
+// handleMarkNotificationsRead marks all of the caller's notifications as
+// read.
+func (cfg *apiConfig) handleMarkNotificationsRead(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	if err := cfg.db.MarkNotificationsRead(req.Context(), userID); err != nil {
+		log.Printf("Error marking notifications read: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handleGetNotificationPreferences(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	cfg.writeNotificationPreferences(w, req, userID)
+}
+
+// handleUpdateNotificationPreferences turns notification types on or off
+// for the caller. Types left out of the body keep their current setting.
+func (cfg *apiConfig) handleUpdateNotificationPreferences(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	incomingJSON := map[string]bool{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+	for notificationType := range incomingJSON {
+		if !slices.Contains(notificationTypes, notificationType) {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: fmt.Sprintf("Unknown notification type %q", notificationType),
+			})
+			return
+		}
+	}
+
+	if err := cfg.setNotificationPreferences(req.Context(), userID, incomingJSON); err != nil {
+		log.Printf("Error updating notification preferences: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	cfg.writeNotificationPreferences(w, req, userID)
+}
+
+// setNotificationPreferences records which notification types userID
+// wants in one transaction.
+func (cfg *apiConfig) setNotificationPreferences(ctx context.Context, userID uuid.UUID, enabled map[string]bool) error {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	for notificationType, on := range enabled {
+		if on {
+			err = qtx.DeleteNotificationOptOut(ctx, database.DeleteNotificationOptOutParams{
+				UserID: userID,
+				Type:   notificationType,
+			})
+		} else {
+			err = qtx.CreateNotificationOptOut(ctx, database.CreateNotificationOptOutParams{
+				UserID: userID,
+				Type:   notificationType,
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// writeNotificationPreferences writes whether userID gets each type of
+// notification, as a JSON object keyed by type.
+func (cfg *apiConfig) writeNotificationPreferences(w http.ResponseWriter, req *http.Request, userID uuid.UUID) {
+	optOuts, err := cfg.db.ListNotificationOptOuts(req.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching notification preferences: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	preferences := map[string]bool{}
+	for _, notificationType := range notificationTypes {
+		preferences[notificationType] = !slices.Contains(optOuts, notificationType)
+	}
+	writeJSON(w, http.StatusOK, preferences)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/flames31/Chirpy/internal/pagination"
+)
+
+// parsePage reads the "cursor" and "limit" query parameters. A missing
+// cursor yields the zero Cursor, which sorts before every row, or
+// pagination.End for descending lists.
+func parsePage(req *http.Request, desc bool) (pagination.Cursor, int, error) {
+	limit, err := pagination.ParseLimit(req.URL.Query().Get("limit"))
+	if err != nil {
+		return pagination.Cursor{}, 0, err
+	}
+
+	cursorStr := req.URL.Query().Get("cursor")
+	if cursorStr == "" {
+		if desc {
+			return pagination.End, limit, nil
+		}
+		return pagination.Cursor{}, limit, nil
+	}
+
+	cursor, err := pagination.Decode(cursorStr)
+	if err != nil {
+		return pagination.Cursor{}, 0, err
+	}
+
+	return cursor, limit, nil
+}
+
+// setNextLink advertises the following page in a Link header, keeping
+// every other query parameter of the current request.
+func setNextLink(w http.ResponseWriter, req *http.Request, next pagination.Cursor) {
+	query := req.URL.Query()
+	query.Set("cursor", pagination.Encode(next))
+
+	w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, req.URL.Path, query.Encode()))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxPinnedChirps    = 1
+	redMaxPinnedChirps = 3
+)
+
+var errTooManyPins = errors.New("pin limit reached")
+
+// pinLimit is how many chirps a user may pin. Chirpy Red members get a
+// higher limit. Pins beyond the limit, left over from a lapsed Red
+// membership, are kept but not shown.
+func pinLimit(user database.User) int {
+	if user.IsChirpyRed {
+		return redMaxPinnedChirps
+	}
+	return maxPinnedChirps
+}
+
+// handlePinChirp pins one of the caller's chirps after any they already
+// pinned. Pinning a pinned chirp again changes nothing.
+func (cfg *apiConfig) handlePinChirp(w http.ResponseWriter, req *http.Request) {
+	chirp, ok := cfg.ownChirp(w, req)
+	if !ok {
+		return
+	}
+
+	limit, err := cfg.pinChirp(req.Context(), chirp)
+	if errors.Is(err, errTooManyPins) {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: fmt.Sprintf("You can pin at most %d chirps", limit),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error pinning chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pinChirp pins chirp for its author unless they are at their pin limit,
+// which it returns. The author's row is locked for the duration, so two
+// concurrent pins cannot both take the last slot.
+func (cfg *apiConfig) pinChirp(ctx context.Context, chirp database.Chirp) (int, error) {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	user, err := qtx.LockUser(ctx, chirp.UserID)
+	if err != nil {
+		return 0, err
+	}
+	limit := pinLimit(user)
+
+	pinned, err := qtx.CountPinnedChirps(ctx, database.CountPinnedChirpsParams{
+		UserID:        user.ID,
+		ExceptChirpID: chirp.ID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if pinned >= int64(limit) {
+		return limit, errTooManyPins
+	}
+
+	err = qtx.CreatePinnedChirp(ctx, database.CreatePinnedChirpParams{
+		UserID:  user.ID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return limit, tx.Commit()
+}
+
+func (cfg *apiConfig) handleUnpinChirp(w http.ResponseWriter, req *http.Request) {
+	chirp, ok := cfg.ownChirp(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.DeletePinnedChirp(req.Context(), database.DeletePinnedChirpParams{
+		UserID:  chirp.UserID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		log.Printf("Error unpinning chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listPinnedChirps returns the chirps authorID has pinned, in pin order,
+// up to their current pin limit.
+func (cfg *apiConfig) listPinnedChirps(ctx context.Context, authorID, viewerID uuid.UUID) ([]database.Chirp, error) {
+	author, err := cfg.db.GetUserByID(ctx, authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.db.ListPinnedChirps(ctx, database.ListPinnedChirpsParams{
+		UserID:   authorID,
+		ViewerID: viewerID,
+		MaxRows:  int32(pinLimit(author)),
+	})
+}
+
+// writeAuthorChirps writes a page of an author's chirps. The first page
+// starts with their pinned chirps, and pinned chirps are left out of the
+// chronological pages, so none is listed twice.
+func (cfg *apiConfig) writeAuthorChirps(w http.ResponseWriter, req *http.Request, q chirpQuery, chirps []database.Chirp, firstPage bool) {
+	pinned, err := cfg.listPinnedChirps(req.Context(), q.AuthorID.UUID, q.ViewerID)
+	if err != nil {
+		log.Printf("Error fetching pinned chirps: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	pinnedIDs := map[uuid.UUID]bool{}
+	for _, chirp := range pinned {
+		pinnedIDs[chirp.ID] = true
+	}
+
+	page := []database.Chirp{}
+	if firstPage {
+		page = append(page, pinned...)
+	}
+	for _, chirp := range chirps {
+		if !pinnedIDs[chirp.ID] {
+			page = append(page, chirp)
+		}
+	}
+
+	chirpsJSON, err := cfg.renderChirps(req.Context(), q.ViewerID, page)
+	if err != nil {
+		log.Printf("Error rendering chirps: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	for i := range chirpsJSON {
+		chirpsJSON[i].Pinned = pinnedIDs[chirpsJSON[i].ID]
+	}
+
+	writeJSON(w, http.StatusOK, chirpsJSON)
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/chirptext"
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/moderation"
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 50
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// pollInput is the poll a client attaches to a new chirp.
+type pollInput struct {
+	Options        []string  `json:"options"`
+	MultipleChoice bool      `json:"multiple_choice"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type pollOptionJSON struct {
+	ID    uuid.UUID `json:"id"`
+	Text  string    `json:"text"`
+	Votes *int64    `json:"votes"`
+}
+
+// pollJSON is the poll on a chirp. Voters and the per-option votes stay
+// null until the viewer has voted or the poll has closed, so running
+// totals cannot sway anyone who has not voted yet.
+type pollJSON struct {
+	MultipleChoice bool             `json:"multiple_choice"`
+	ExpiresAt      time.Time        `json:"expires_at"`
+	Closed         bool             `json:"closed"`
+	Voters         *int64           `json:"voters"`
+	Options        []pollOptionJSON `json:"options"`
+	MyVotes        []uuid.UUID      `json:"my_votes,omitempty"`
+}
+
+// validatePoll checks a poll and runs its options through moderation,
+// returning the rules they matched. On failure it returns the message to
+// send back.
+func (cfg *apiConfig) validatePoll(poll pollInput) (pollInput, []moderation.Rule, string) {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return pollInput{}, nil, fmt.Sprintf("A poll needs between %d and %d options", minPollOptions, maxPollOptions)
+	}
+
+	seen := map[string]bool{}
+	matched := []moderation.Rule{}
+	options := make([]string, 0, len(poll.Options))
+	for _, option := range poll.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || chirptext.Length(option) > maxPollOptionLength {
+			return pollInput{}, nil, fmt.Sprintf("Poll options must be between 1 and %d characters", maxPollOptionLength)
+		}
+		if seen[strings.ToLower(option)] {
+			return pollInput{}, nil, "Poll options must be different"
+		}
+		seen[strings.ToLower(option)] = true
+
+		result := cfg.moderate(option)
+		if result.Rejected {
+			return pollInput{}, nil, "Poll options contain language that is not allowed"
+		}
+		matched = append(matched, result.Matched...)
+		options = append(options, result.Text)
+	}
+
+	untilExpiry := time.Until(poll.ExpiresAt)
+	if untilExpiry < minPollDuration || untilExpiry > maxPollDuration {
+		return pollInput{}, nil, fmt.Sprintf("expires_at must be between %s and %s from now", minPollDuration, maxPollDuration)
+	}
+
+	return pollInput{
+		Options:        options,
+		MultipleChoice: poll.MultipleChoice,
+		ExpiresAt:      poll.ExpiresAt.UTC(),
+	}, matched, ""
+}
+
+// createPoll stores a validated poll for a new chirp inside the caller's
+// transaction.
+func createPoll(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID, poll pollInput) error {
+	err := qtx.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:        chirpID,
+		MultipleChoice: poll.MultipleChoice,
+		ExpiresAt:      poll.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, option := range poll.Options {
+		err := qtx.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ChirpID:  chirpID,
+			Position: int16(i),
+			Text:     option,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPolls fetches the polls on several chirps at once, keyed by chirp
+// ID, with tallies filled in only where viewerID may see them.
+func (cfg *apiConfig) loadPolls(ctx context.Context, viewerID uuid.UUID, chirpIDs []uuid.UUID) (map[uuid.UUID]*pollJSON, error) {
+	polls := map[uuid.UUID]*pollJSON{}
+
+	rows, err := cfg.db.GetPollsByChirpIDs(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return polls, nil
+	}
+
+	pollIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		pollIDs = append(pollIDs, row.ChirpID)
+	}
+
+	myVotes := map[uuid.UUID][]uuid.UUID{}
+	if viewerID != uuid.Nil {
+		choices, err := cfg.db.ListPollChoicesByUser(ctx, database.ListPollChoicesByUserParams{
+			UserID:   viewerID,
+			ChirpIds: pollIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, choice := range choices {
+			myVotes[choice.ChirpID] = append(myVotes[choice.ChirpID], choice.OptionID)
+		}
+	}
+
+	now := time.Now()
+	for _, row := range rows {
+		poll := &pollJSON{
+			MultipleChoice: row.MultipleChoice,
+			ExpiresAt:      row.ExpiresAt,
+			Closed:         !now.Before(row.ExpiresAt),
+			Options:        []pollOptionJSON{},
+			MyVotes:        myVotes[row.ChirpID],
+		}
+		if poll.Closed || len(poll.MyVotes) > 0 {
+			voters := row.Voters
+			poll.Voters = &voters
+		}
+		polls[row.ChirpID] = poll
+	}
+
+	options, err := cfg.db.ListPollOptionsByChirpIDs(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		poll := polls[option.ChirpID]
+		o := pollOptionJSON{ID: option.ID, Text: option.Text}
+		if poll.Voters != nil {
+			votes := option.Votes
+			o.Votes = &votes
+		}
+		poll.Options = append(poll.Options, o)
+	}
+
+	return polls, nil
+}
+
+func (cfg *apiConfig) handleVotePoll(w http.ResponseWriter, req *http.Request) {
+	type incoming struct {
+		OptionIDs []uuid.UUID `json:"option_ids"`
+	}
+
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "chirpID must be a valid UUID",
+		})
+		return
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpByID(req.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid || cfg.blockedEitherWay(req.Context(), userID, chirp.UserID) {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+
+	polls, err := cfg.db.GetPollsByChirpIDs(req.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		log.Printf("Error fetching poll: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	if len(polls) == 0 {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Poll not found",
+		})
+		return
+	}
+	poll := polls[0]
+
+	if !time.Now().Before(poll.ExpiresAt) {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: "Poll is closed",
+		})
+		return
+	}
+
+	if len(incomingJSON.OptionIDs) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Choose at least one option",
+		})
+		return
+	}
+	if !poll.MultipleChoice && len(incomingJSON.OptionIDs) > 1 {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "This poll allows only one choice",
+		})
+		return
+	}
+
+	options, err := cfg.db.ListPollOptionsByChirpIDs(req.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		log.Printf("Error fetching poll options: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+	available := map[uuid.UUID]bool{}
+	for _, option := range options {
+		available[option.ID] = true
+	}
+	for _, optionID := range incomingJSON.OptionIDs {
+		if !available[optionID] {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "option_ids must reference different options of this poll",
+			})
+			return
+		}
+		delete(available, optionID)
+	}
+
+	err = cfg.castBallot(req.Context(), chirpID, userID, incomingJSON.OptionIDs)
+	if isUniqueViolation(err, "poll_ballots_pkey") {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: "You have already voted",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error casting ballot: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	cfg.writeChirp(w, req, http.StatusCreated, chirp)
+}
+
+// castBallot records a user's choices in one transaction. The ballot's
+// primary key is what guarantees a single vote per user, even when two
+// requests race.
+func (cfg *apiConfig) castBallot(ctx context.Context, chirpID, userID uuid.UUID, optionIDs []uuid.UUID) error {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	err = qtx.CreatePollBallot(ctx, database.CreatePollBallotParams{
+		ChirpID: chirpID,
+		UserID:  userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, optionID := range optionIDs {
+		err := qtx.CreatePollBallotChoice(ctx, database.CreatePollBallotChoiceParams{
+			ChirpID:  chirpID,
+			UserID:   userID,
+			OptionID: optionID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
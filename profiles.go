@@ -0,0 +1,219 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxAvatarURLLength   = 2048
+)
+
+// profileJSON is the public view of a user. It never includes the email.
+type profileJSON struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Handle         *string   `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	AvatarURL      string    `json:"avatar_url"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	ChirpCount     int64     `json:"chirp_count"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+}
+
+func (cfg *apiConfig) handleGetProfile(w http.ResponseWriter, req *http.Request) {
+	handleOrID := req.PathValue("handleOrID")
+
+	var user database.User
+	var err error
+	if handleOrID == "me" {
+		userID, authErr := cfg.authenticatedUserID(req)
+		if authErr != nil {
+			writeJSON(w, http.StatusUnauthorized, errorJSON{
+				Error: "User not authorized",
+			})
+			return
+		}
+		user, err = cfg.db.GetUserByID(req.Context(), userID)
+	} else if userID, parseErr := uuid.Parse(handleOrID); parseErr == nil {
+		user, err = cfg.db.GetUserByID(req.Context(), userID)
+	} else {
+		user, err = cfg.db.GetUserByHandle(req.Context(), handleOrID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "User not found",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching user: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	cfg.writeProfile(w, req, user)
+}
+
+func (cfg *apiConfig) handleUpdateProfile(w http.ResponseWriter, req *http.Request) {
+	type incoming struct {
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		AvatarURL   *string `json:"avatar_url"`
+	}
+
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+
+	user, err := cfg.db.GetUserByID(req.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching user: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	params := database.UpdateUserProfileParams{
+		ID:          user.ID,
+		Handle:      user.Handle,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarUrl:   user.AvatarUrl,
+	}
+	if incomingJSON.Handle != nil {
+		if err := validateHandle(*incomingJSON.Handle); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+			return
+		}
+		params.Handle = sql.NullString{String: *incomingJSON.Handle, Valid: true}
+	}
+	if incomingJSON.DisplayName != nil {
+		if err := validateProfileText(*incomingJSON.DisplayName, maxDisplayNameLength, false); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorJSON{Error: "display_name " + err.Error()})
+			return
+		}
+		params.DisplayName = *incomingJSON.DisplayName
+	}
+	if incomingJSON.Bio != nil {
+		if err := validateProfileText(*incomingJSON.Bio, maxBioLength, true); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorJSON{Error: "bio " + err.Error()})
+			return
+		}
+		params.Bio = *incomingJSON.Bio
+	}
+	if incomingJSON.AvatarURL != nil {
+		if err := validateAvatarURL(*incomingJSON.AvatarURL); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+			return
+		}
+		params.AvatarUrl = *incomingJSON.AvatarURL
+	}
+
+	user, err = cfg.db.UpdateUserProfile(req.Context(), params)
+	if isUniqueViolation(err, "users_handle_idx") {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: "Handle is already taken",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating profile: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	cfg.writeProfile(w, req, user)
+}
+
+func (cfg *apiConfig) writeProfile(w http.ResponseWriter, req *http.Request, user database.User) {
+	counts, err := cfg.db.GetUserProfileCounts(req.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error fetching profile counts: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profileJSON{
+		ID:             user.ID,
+		CreatedAt:      user.CreatedAt,
+		Handle:         nullStringPtr(user.Handle),
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		AvatarURL:      user.AvatarUrl,
+		IsChirpyRed:    user.IsChirpyRed,
+		ChirpCount:     counts.ChirpCount,
+		FollowerCount:  counts.FollowerCount,
+		FollowingCount: counts.FollowingCount,
+	})
+}
+
+// validateProfileText limits free-form profile fields to maxLength
+// characters without control characters. Bios may span several lines.
+func validateProfileText(s string, maxLength int, allowNewlines bool) error {
+	if !utf8.ValidString(s) {
+		return errors.New("must be valid UTF-8")
+	}
+	if utf8.RuneCountInString(s) > maxLength {
+		return errors.New("is too long")
+	}
+	for _, r := range s {
+		if r == '\n' && allowNewlines {
+			continue
+		}
+		if unicode.IsControl(r) {
+			return errors.New("cannot contain control characters")
+		}
+	}
+	return nil
+}
+
+// validateAvatarURL accepts an empty string, which removes the avatar, or
+// an absolute http(s) URL.
+func validateAvatarURL(s string) error {
+	if s == "" {
+		return nil
+	}
+	if len(s) > maxAvatarURLLength {
+		return errors.New("avatar_url is too long")
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("avatar_url must be an http or https URL")
+	}
+	return nil
+}
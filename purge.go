@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const chirpPurgeInterval = time.Hour
+
+// runChirpPurger hard-deletes chirps that have been soft-deleted for
+// longer than cfg.chirpRetention, checking once per chirpPurgeInterval
+// until ctx is cancelled. Chirps still replied to or rechirped are left
+// as tombstones. Deleting the same rows from several instances at once is
+// harmless.
+func (cfg *apiConfig) runChirpPurger(ctx context.Context) {
+	ticker := time.NewTicker(chirpPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := cfg.db.PurgeDeletedChirps(ctx, time.Now().Add(-cfg.chirpRetention))
+		if err != nil {
+			log.Printf("Error purging deleted chirps: %s", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d deleted chirps", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
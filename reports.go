@@ -0,0 +1,586 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/chirptext"
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+// reportReasons are the reason codes a user can pick when reporting a
+// chirp.
+var reportReasons = []string{
+	"spam",
+	"harassment",
+	"hate",
+	"violence",
+	"sexual",
+	"self_harm",
+	"misinformation",
+	"other",
+}
+
+const maxReportDetailsLength = 500
+
+const (
+	moderationDismiss = "dismiss"
+	moderationHide    = "hide"
+	moderationDelete  = "delete"
+	moderationBan     = "ban"
+	moderationSetRole = "set_role"
+)
+
+// chirpFlagJSON is a report by a user, or a flag raised by the moderation
+// filter, in which case ReporterID is null and Reason lists the rules
+// that matched.
+type chirpFlagJSON struct {
+	ID         uuid.UUID  `json:"id"`
+	ChirpID    uuid.UUID  `json:"chirp_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Source     string     `json:"source"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details"`
+	ReporterID *uuid.UUID `json:"reporter_id"`
+}
+
+func newChirpFlagJSON(flag database.ChirpFlag) chirpFlagJSON {
+	f := chirpFlagJSON{
+		ID:        flag.ID,
+		ChirpID:   flag.ChirpID,
+		CreatedAt: flag.CreatedAt,
+		Source:    flag.Source,
+		Reason:    flag.Reason,
+		Details:   flag.Details,
+	}
+	if flag.ReporterID.Valid {
+		f.ReporterID = &flag.ReporterID.UUID
+	}
+	return f
+}
+
+// moderationQueueJSON is one chirp awaiting review with all of its open
+// flags. Reasons counts the flags by report reason, with filter flags
+// counted under "filter".
+type moderationQueueJSON struct {
+	Chirp          chirpJSON       `json:"chirp"`
+	Author         userSummaryJSON `json:"author"`
+	FirstFlaggedAt time.Time       `json:"first_flagged_at"`
+	Reasons        map[string]int  `json:"reasons"`
+	Flags          []chirpFlagJSON `json:"flags"`
+}
+
+type moderationActionJSON struct {
+	ID            uuid.UUID  `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ActorID       *uuid.UUID `json:"actor_id"`
+	Action        string     `json:"action"`
+	ChirpID       *uuid.UUID `json:"chirp_id"`
+	TargetUserID  *uuid.UUID `json:"target_user_id"`
+	Note          string     `json:"note"`
+	FlagsResolved int32      `json:"flags_resolved"`
+}
+
+func newModerationActionJSON(action database.ModerationAction) moderationActionJSON {
+	a := moderationActionJSON{
+		ID:            action.ID,
+		CreatedAt:     action.CreatedAt,
+		Action:        action.Action,
+		Note:          action.Note,
+		FlagsResolved: action.FlagsResolved,
+	}
+	if action.ActorID.Valid {
+		a.ActorID = &action.ActorID.UUID
+	}
+	if action.ChirpID.Valid {
+		a.ChirpID = &action.ChirpID.UUID
+	}
+	if action.TargetUserID.Valid {
+		a.TargetUserID = &action.TargetUserID.UUID
+	}
+	return a
+}
+
+func (cfg *apiConfig) handleReportChirp(w http.ResponseWriter, req *http.Request) {
+	type incoming struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "chirpID must be a valid UUID",
+		})
+		return
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+
+	if !slices.Contains(reportReasons, incomingJSON.Reason) {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: fmt.Sprintf("reason must be one of %v", reportReasons),
+		})
+		return
+	}
+	if chirptext.Length(incomingJSON.Details) > maxReportDetailsLength {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: fmt.Sprintf("details must be at most %d characters", maxReportDetailsLength),
+		})
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpByID(req.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid || cfg.blockedEitherWay(req.Context(), userID, chirp.UserID) {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+	if chirp.UserID == userID {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "You cannot report your own chirp",
+		})
+		return
+	}
+
+	report, err := cfg.db.CreateChirpReport(req.Context(), database.CreateChirpReportParams{
+		ChirpID:    chirpID,
+		Reason:     incomingJSON.Reason,
+		ReporterID: userID,
+		Details:    incomingJSON.Details,
+	})
+	if isUniqueViolation(err, "chirp_flags_open_report_idx") {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: "You have already reported this chirp",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error reporting chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, newChirpFlagJSON(report))
+}
+
+// handleGetModerationQueue lists chirps with open flags, oldest flag
+// first, so the chirps that have waited longest are handled first.
+func (cfg *apiConfig) handleGetModerationQueue(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireStaff(w, req); !ok {
+		return
+	}
+
+	cursor, limit, err := parsePage(req, false)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	rows, err := cfg.db.ListOpenFlaggedChirps(req.Context(), database.ListOpenFlaggedChirpsParams{
+		AfterCreatedAt: cursor.CreatedAt,
+		AfterID:        cursor.ID,
+		MaxRows:        int32(limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error listing moderation queue: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.FirstFlaggedAt, ID: last.ChirpID})
+	}
+
+	queue, err := cfg.loadModerationQueue(req.Context(), rows)
+	if err != nil {
+		log.Printf("Error loading moderation queue: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, queue)
+}
+
+// loadModerationQueue fetches the chirps, authors and open flags for a
+// page of the queue in one batch each.
+func (cfg *apiConfig) loadModerationQueue(ctx context.Context, rows []database.ListOpenFlaggedChirpsRow) ([]moderationQueueJSON, error) {
+	queue := []moderationQueueJSON{}
+	if len(rows) == 0 {
+		return queue, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ChirpID)
+	}
+
+	chirps, err := cfg.db.GetChirpsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	chirpsByID := map[uuid.UUID]database.Chirp{}
+	authorIDs := []uuid.UUID{}
+	for _, chirp := range chirps {
+		chirpsByID[chirp.ID] = chirp
+		authorIDs = append(authorIDs, chirp.UserID)
+	}
+
+	authors, err := cfg.db.GetUsersByIDs(ctx, authorIDs)
+	if err != nil {
+		return nil, err
+	}
+	authorsByID := map[uuid.UUID]database.User{}
+	for _, author := range authors {
+		authorsByID[author.ID] = author
+	}
+
+	flags, err := cfg.db.ListOpenFlagsByChirpIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	flagsByChirp := map[uuid.UUID][]database.ChirpFlag{}
+	for _, flag := range flags {
+		flagsByChirp[flag.ChirpID] = append(flagsByChirp[flag.ChirpID], flag)
+	}
+
+	for _, row := range rows {
+		chirp, ok := chirpsByID[row.ChirpID]
+		if !ok {
+			continue
+		}
+		author := authorsByID[chirp.UserID]
+
+		item := moderationQueueJSON{
+			Chirp:          newChirpJSON(chirp),
+			Author:         userSummaryJSON{ID: author.ID, Handle: nullStringPtr(author.Handle)},
+			FirstFlaggedAt: row.FirstFlaggedAt,
+			Reasons:        map[string]int{},
+			Flags:          []chirpFlagJSON{},
+		}
+		for _, flag := range flagsByChirp[row.ChirpID] {
+			reason := flag.Reason
+			if flag.Source == "filter" {
+				reason = "filter"
+			}
+			item.Reasons[reason]++
+			item.Flags = append(item.Flags, newChirpFlagJSON(flag))
+		}
+		queue = append(queue, item)
+	}
+
+	return queue, nil
+}
+
+// handleModerateChirp resolves every open flag on a chirp with one of
+// the moderation actions and records it in the audit log:
+//
+//   - dismiss leaves the chirp as it is.
+//   - hide soft-deletes the chirp, which an admin can still restore until
+//     it is purged.
+//   - delete soft-deletes the chirp too, leaving its removal to the
+//     purger; only the audit log tells it apart from hide.
+//   - ban bans the author, signs them out everywhere and hides the chirp.
+func (cfg *apiConfig) handleModerateChirp(w http.ResponseWriter, req *http.Request) {
+	type incoming struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+
+	staff, ok := cfg.requireStaff(w, req)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "chirpID must be a valid UUID",
+		})
+		return
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+
+	switch incomingJSON.Action {
+	case moderationDismiss, moderationHide, moderationDelete, moderationBan:
+	default:
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "action must be one of dismiss, hide, delete or ban",
+		})
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpByID(req.Context(), chirpID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+
+	if incomingJSON.Action == moderationBan {
+		author, err := cfg.db.GetUserByID(req.Context(), chirp.UserID)
+		if err != nil {
+			log.Printf("Error fetching user: %s", err)
+			writeJSON(w, http.StatusInternalServerError, errorJSON{
+				Error: "Something went wrong",
+			})
+			return
+		}
+		if author.Role != roleUser {
+			writeJSON(w, http.StatusConflict, errorJSON{
+				Error: "Staff accounts cannot be banned",
+			})
+			return
+		}
+	}
+
+	action, err := cfg.moderateChirp(req.Context(), staff.ID, chirp, incomingJSON.Action, incomingJSON.Note)
+	if err != nil {
+		log.Printf("Error moderating chirp: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newModerationActionJSON(action))
+}
+
+// moderateChirp applies a moderation action, resolves the chirp's open
+// flags and writes the audit entry in one transaction.
+func (cfg *apiConfig) moderateChirp(ctx context.Context, actorID uuid.UUID, chirp database.Chirp, action, note string) (database.ModerationAction, error) {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.ModerationAction{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	resolved, err := qtx.ResolveChirpFlags(ctx, database.ResolveChirpFlagsParams{
+		ChirpID:    chirp.ID,
+		ResolvedBy: actorID,
+		Resolution: action,
+	})
+	if err != nil {
+		return database.ModerationAction{}, err
+	}
+
+	if action == moderationBan {
+		err = qtx.BanUser(ctx, chirp.UserID)
+		if err == nil {
+			err = qtx.RevokeUserRefreshTokens(ctx, chirp.UserID)
+		}
+		if err != nil {
+			return database.ModerationAction{}, err
+		}
+	}
+
+	// A chirp deleted already keeps its deleted_at, so its retention is not
+	// restarted, and streams are not told twice.
+	if action != moderationDismiss && !chirp.DeletedAt.Valid {
+		err = softDeleteChirp(ctx, qtx, chirp.ID)
+		if err == nil {
+			err = publishChirpEvent(ctx, qtx, chirpEventDeleted, chirp)
+		}
+	}
+	if err != nil {
+		return database.ModerationAction{}, err
+	}
+
+	entry, err := qtx.CreateModerationAction(ctx, database.CreateModerationActionParams{
+		ActorID:       actorID,
+		Action:        action,
+		ChirpID:       uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		TargetUserID:  uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+		Note:          note,
+		FlagsResolved: int32(resolved),
+	})
+	if err != nil {
+		return database.ModerationAction{}, err
+	}
+
+	return entry, tx.Commit()
+}
+
+// handleGetModerationActions lists the audit log, newest first.
+func (cfg *apiConfig) handleGetModerationActions(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireStaff(w, req); !ok {
+		return
+	}
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	rows, err := cfg.db.ListModerationActions(req.Context(), database.ListModerationActionsParams{
+		BeforeCreatedAt: cursor.CreatedAt,
+		BeforeID:        cursor.ID,
+		MaxRows:         int32(limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error listing moderation actions: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	actions := []moderationActionJSON{}
+	for _, row := range rows {
+		actions = append(actions, newModerationActionJSON(row))
+	}
+	writeJSON(w, http.StatusOK, actions)
+}
+
+// handleSetUserRole grants or takes away a staff role. Admins cannot
+// change their own role, so there is always at least one admin left.
+func (cfg *apiConfig) handleSetUserRole(w http.ResponseWriter, req *http.Request) {
+	type incoming struct {
+		Role string `json:"role"`
+		Note string `json:"note"`
+	}
+
+	type respJSON struct {
+		ID   uuid.UUID `json:"id"`
+		Role string    `json:"role"`
+	}
+
+	admin, ok := cfg.requireAdmin(w, req)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "userID must be a valid UUID",
+		})
+		return
+	}
+
+	incomingJSON := incoming{}
+	if err := json.NewDecoder(req.Body).Decode(&incomingJSON); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "Invalid JSON body",
+		})
+		return
+	}
+
+	switch incomingJSON.Role {
+	case roleUser, roleModerator, roleAdmin:
+	default:
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "role must be one of user, moderator or admin",
+		})
+		return
+	}
+	if userID == admin.ID {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "You cannot change your own role",
+		})
+		return
+	}
+
+	user, err := cfg.setUserRole(req.Context(), admin.ID, userID, incomingJSON.Role, incomingJSON.Note)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "User not found",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error setting user role: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, respJSON{ID: user.ID, Role: user.Role})
+}
+
+// setUserRole changes a user's role and records it in the audit log, with
+// the new role at the start of the note.
+func (cfg *apiConfig) setUserRole(ctx context.Context, actorID, userID uuid.UUID, role, note string) (database.User, error) {
+	tx, err := cfg.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return database.User{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	user, err := qtx.UpdateUserRole(ctx, database.UpdateUserRoleParams{
+		ID:   userID,
+		Role: role,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+
+	if note != "" {
+		role += ": " + note
+	}
+	_, err = qtx.CreateModerationAction(ctx, database.CreateModerationActionParams{
+		ActorID:      actorID,
+		Action:       moderationSetRole,
+		TargetUserID: uuid.NullUUID{UUID: userID, Valid: true},
+		Note:         role,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+
+	return user, tx.Commit()
+}
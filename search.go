@@ -0,0 +1,94 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+// searchResultJSON is a chirp matching a search. Snippet is an HTML
+// fragment of the escaped body with the matching words in <mark> tags.
+type searchResultJSON struct {
+	chirpJSON
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+func (cfg *apiConfig) handleSearchChirps(w http.ResponseWriter, req *http.Request) {
+	query := strings.TrimSpace(req.URL.Query().Get("q"))
+	if query == "" {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "q is required",
+		})
+		return
+	}
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	rows, err := cfg.db.SearchChirps(req.Context(), database.SearchChirpsParams{
+		Query:      query,
+		ViewerID:   cfg.viewerID(req),
+		BeforeRank: float32(cursor.Rank),
+		BeforeID:   cursor.ID,
+		MaxRows:    int32(limit + 1),
+	})
+	if err != nil {
+		log.Printf("Error searching chirps: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		setNextLink(w, req, pagination.Cursor{Rank: float64(last.Rank), ID: last.Chirp.ID})
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+
+	chirpsJSON, err := cfg.renderChirps(req.Context(), cfg.viewerID(req), chirps)
+	if err != nil {
+		log.Printf("Error rendering chirps: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	// renderChirps drops chirps hidden from the viewer, which may include
+	// some that were not hidden when the search ran.
+	rendered := map[uuid.UUID]chirpJSON{}
+	for _, chirp := range chirpsJSON {
+		rendered[chirp.ID] = chirp
+	}
+
+	results := []searchResultJSON{}
+	for _, row := range rows {
+		chirp, ok := rendered[row.Chirp.ID]
+		if !ok {
+			continue
+		}
+		results = append(results, searchResultJSON{
+			chirpJSON: chirp,
+			Rank:      row.Rank,
+			Snippet:   row.Snippet,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
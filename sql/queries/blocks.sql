@@ -0,0 +1,48 @@
+-- name: CreateBlock :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBlock :exec
+DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: ListBlocks :many
+SELECT users.id, users.handle, blocks.created_at
+FROM blocks
+JOIN users ON users.id = blocks.blocked_id
+WHERE blocks.blocker_id = sqlc.arg(user_id)
+AND (blocks.created_at, blocks.blocked_id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY blocks.created_at DESC, blocks.blocked_id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: IsBlockedEitherWay :one
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = sqlc.arg(user_a) AND blocked_id = sqlc.arg(user_b))
+    OR (blocker_id = sqlc.arg(user_b) AND blocked_id = sqlc.arg(user_a))
+);
+
+-- name: ListBlockedEitherWay :many
+SELECT (CASE WHEN blocker_id = sqlc.arg(user_id) THEN blocked_id ELSE blocker_id END)::uuid AS other_id
+FROM blocks
+WHERE blocker_id = sqlc.arg(user_id) OR blocked_id = sqlc.arg(user_id);
+
+-- name: CreateMute :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMute :exec
+DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: ListMutes :many
+SELECT users.id, users.handle, mutes.created_at
+FROM mutes
+JOIN users ON users.id = mutes.muted_id
+WHERE mutes.muter_id = sqlc.arg(user_id)
+AND (mutes.created_at, mutes.muted_id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY mutes.created_at DESC, mutes.muted_id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListHiddenUserIDs :many
+SELECT DISTINCT hidden_id FROM hidden_users WHERE viewer_id = $1;
\ No newline at end of file
@@ -0,0 +1,31 @@
+-- name: UpsertBookmark :exec
+-- Bookmarking a chirp again only moves it to another collection; it keeps
+-- its place in the list.
+INSERT INTO bookmarks (user_id, chirp_id, created_at, collection_id)
+VALUES ($1, $2, NOW(), $3)
+ON CONFLICT (user_id, chirp_id) DO UPDATE SET collection_id = EXCLUDED.collection_id;
+
+-- name: DeleteBookmark :exec
+DELETE FROM bookmarks WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: ListBookmarks :many
+-- Bookmarks of deleted chirps, or of chirps by users hidden from the
+-- owner, are left out rather than listed as dangling IDs.
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at
+FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg(user_id)
+AND (sqlc.narg(collection_id)::uuid IS NULL OR bookmarks.collection_id = sqlc.narg(collection_id)::uuid)
+AND chirps.deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(user_id) AND hidden_users.hidden_id = chirps.user_id
+)
+AND (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = sqlc.arg(user_id)
+AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
\ No newline at end of file
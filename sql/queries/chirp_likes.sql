@@ -0,0 +1,32 @@
+-- name: CreateChirpLike :execrows
+INSERT INTO chirp_likes (user_id, chirp_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpLike :exec
+DELETE FROM chirp_likes WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: ListChirpLikers :many
+-- Leaves out users hidden from the viewer.
+SELECT users.id, users.handle, chirp_likes.created_at AS liked_at
+FROM chirp_likes
+JOIN users ON users.id = chirp_likes.user_id
+WHERE chirp_likes.chirp_id = sqlc.arg(chirp_id)
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(viewer_id) AND hidden_users.hidden_id = users.id
+)
+AND (chirp_likes.created_at, chirp_likes.user_id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY chirp_likes.created_at DESC, chirp_likes.user_id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: CountLikesByChirpIDs :many
+SELECT chirp_id, COUNT(*) AS likes
+FROM chirp_likes
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY chirp_id;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = sqlc.arg(user_id)
+AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
\ No newline at end of file
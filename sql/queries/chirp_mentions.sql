@@ -0,0 +1,23 @@
+-- name: CreateChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions WHERE chirp_id = $1;
+
+-- name: ListMentionsForUser :many
+SELECT chirps.* FROM chirps
+JOIN chirp_mentions ON chirp_mentions.chirp_id = chirps.id
+WHERE chirp_mentions.user_id = sqlc.arg(user_id)
+AND chirps.deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(viewer_id) AND hidden_users.hidden_id = chirps.user_id
+)
+AND (chirps.created_at, chirps.id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListChirpMentionUserIDs :many
+SELECT user_id FROM chirp_mentions WHERE chirp_id = $1;
\ No newline at end of file
@@ -1,19 +1,194 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, kind, reference_chirp_id)
 VALUES (
     gen_random_uuid (),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5
 )
 RETURNING *;
 
--- name: GetAllChirps :many
-SELECT * FROM chirps ORDER BY created_at;
+-- name: ListChirps :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(viewer_id) AND hidden_users.hidden_id = chirps.user_id
+)
+AND (created_at, id) > (sqlc.arg(after_created_at)::timestamp, sqlc.arg(after_id)::uuid)
+ORDER BY created_at, id
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(viewer_id) AND hidden_users.hidden_id = chirps.user_id
+)
+AND (created_at, id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListChirpsByAuthor :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(viewer_id) AND hidden_users.hidden_id = chirps.user_id
+)
+AND (created_at, id) > (sqlc.arg(after_created_at)::timestamp, sqlc.arg(after_id)::uuid)
+ORDER BY created_at, id
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListChirpsByAuthorDesc :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+AND deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(viewer_id) AND hidden_users.hidden_id = chirps.user_id
+)
+AND (created_at, id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_rows);
 
 -- name: GetChirpByID :one
 SELECT * FROM chirps WHERE id = $1;
 
+-- name: LockChirp :one
+SELECT * FROM chirps WHERE id = $1 FOR UPDATE;
+
+-- name: GetChirpsByIDs :many
+SELECT * FROM chirps WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
 -- name: DeleteChirpByID :exec
-DELETE FROM chirps WHERE id = $1;
\ No newline at end of file
+DELETE FROM chirps WHERE id = $1;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2,
+updated_at = NOW(),
+edited_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET deleted_at = NOW(),
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL,
+updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: PurgeDeletedChirps :execrows
+-- Tombstones that replies or rechirps still point at are kept, so threads
+-- hold together. They go once everything pointing at them has been purged.
+DELETE FROM chirps
+WHERE deleted_at < sqlc.arg(deleted_before)::timestamp
+AND NOT EXISTS (SELECT 1 FROM chirps AS reply WHERE reply.in_reply_to = chirps.id)
+AND NOT EXISTS (SELECT 1 FROM chirps AS ref WHERE ref.reference_chirp_id = chirps.id);
+
+-- name: CountRepliesByChirpIDs :many
+SELECT in_reply_to::uuid AS chirp_id, COUNT(*) AS replies
+FROM chirps
+WHERE in_reply_to = ANY(sqlc.arg(chirp_ids)::uuid[])
+AND deleted_at IS NULL
+GROUP BY in_reply_to;
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+    SELECT parent.id, parent.in_reply_to, 1 AS depth
+    FROM chirps parent
+    WHERE parent.id = (SELECT start.in_reply_to FROM chirps start WHERE start.id = sqlc.arg(chirp_id))
+    UNION ALL
+    SELECT chirps.id, chirps.in_reply_to, ancestors.depth + 1
+    FROM chirps
+    JOIN ancestors ON chirps.id = ancestors.in_reply_to
+    WHERE ancestors.depth < sqlc.arg(max_depth)::int
+)
+SELECT chirps.* FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+ORDER BY ancestors.depth DESC;
+
+-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+    SELECT reply.id, 1 AS depth
+    FROM chirps reply
+    WHERE reply.in_reply_to = sqlc.arg(chirp_id)::uuid
+    UNION ALL
+    SELECT chirps.id, descendants.depth + 1
+    FROM chirps
+    JOIN descendants ON chirps.in_reply_to = descendants.id
+    WHERE descendants.depth < sqlc.arg(max_depth)::int
+)
+SELECT chirps.* FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+ORDER BY chirps.created_at, chirps.id
+LIMIT sqlc.arg(max_rows);
+
+-- name: SearchChirps :many
+-- The snippet is HTML element content: &, < and > in the body are escaped
+-- before the matches are wrapped in <mark> tags. The text search parser
+-- reads the entities as entities rather than words, so escaping does not
+-- change what is highlighted.
+SELECT sqlc.embed(chirps),
+    ranked.rank::real AS rank,
+    ts_headline('english',
+        replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        websearch_to_tsquery('english', sqlc.arg(query)),
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')::text AS snippet
+FROM (
+    SELECT id, ts_rank(search, websearch_to_tsquery('english', sqlc.arg(query))) AS rank
+    FROM chirps
+    WHERE search @@ websearch_to_tsquery('english', sqlc.arg(query))
+    AND deleted_at IS NULL
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = sqlc.arg(viewer_id) AND hidden_users.hidden_id = chirps.user_id
+    )
+) ranked
+JOIN chirps ON chirps.id = ranked.id
+WHERE (ranked.rank, ranked.id) < (sqlc.arg(before_rank)::real, sqlc.arg(before_id)::uuid)
+ORDER BY ranked.rank DESC, ranked.id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: NotifyChirpEvent :exec
+-- Inside a transaction the notification is only sent on commit. The
+-- snapshot lists the transactions that had committed by then, all of
+-- whose notifications are delivered before this one.
+SELECT pg_notify('chirp_events', json_build_object(
+    'type', sqlc.arg(type)::text,
+    'chirp_id', sqlc.arg(chirp_id)::uuid,
+    'user_id', sqlc.arg(user_id)::uuid,
+    'snapshot', pg_current_snapshot()::text
+)::text);
+
+-- name: GetCurrentSnapshot :one
+SELECT pg_current_snapshot()::text;
+
+-- name: ListChirpsCommittedSince :many
+-- Chirps whose transaction had not committed when snapshot was taken,
+-- oldest first, leaving out except_id.
+SELECT * FROM chirps
+WHERE created_xid >= pg_snapshot_xmin(sqlc.arg(snapshot)::text::pg_snapshot)
+AND NOT pg_visible_in_snapshot(created_xid, sqlc.arg(snapshot)::text::pg_snapshot)
+AND id <> sqlc.arg(except_id)::uuid
+AND deleted_at IS NULL
+AND (sqlc.narg(author_id)::uuid IS NULL OR user_id = sqlc.narg(author_id)::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(viewer_id) AND hidden_users.hidden_id = chirps.user_id
+)
+ORDER BY created_at, id
+LIMIT sqlc.arg(max_rows);
\ No newline at end of file
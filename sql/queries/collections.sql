@@ -0,0 +1,44 @@
+-- name: CreateCollection :one
+INSERT INTO collections (id, user_id, created_at, updated_at, name, position)
+VALUES (
+    gen_random_uuid (),
+    sqlc.arg(user_id),
+    NOW(),
+    NOW(),
+    sqlc.arg(name),
+    (SELECT COALESCE(MAX(position) + 1, 0) FROM collections WHERE user_id = sqlc.arg(user_id))
+)
+RETURNING *;
+
+-- name: CountCollectionsByUser :one
+SELECT COUNT(*) FROM collections WHERE user_id = $1;
+
+-- name: ListCollectionsByUser :many
+SELECT * FROM collections
+WHERE user_id = $1
+ORDER BY position, created_at, id;
+
+-- name: LockCollectionsByUser :many
+SELECT * FROM collections
+WHERE user_id = $1
+ORDER BY position, created_at, id
+FOR UPDATE;
+
+-- name: GetCollectionByID :one
+SELECT * FROM collections WHERE id = $1 AND user_id = $2;
+
+-- name: RenameCollection :one
+UPDATE collections
+SET name = $3,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: SetCollectionPosition :exec
+UPDATE collections
+SET position = $3,
+updated_at = NOW()
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteCollection :execrows
+DELETE FROM collections WHERE id = $1 AND user_id = $2;
\ No newline at end of file
@@ -0,0 +1,77 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at, name, direct_key)
+VALUES (
+    gen_random_uuid (),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: GetConversationByDirectKey :one
+SELECT * FROM conversations WHERE direct_key = $1;
+
+-- name: CreateConversationMember :exec
+-- Messages sent before the member joined count as read.
+INSERT INTO conversation_members (conversation_id, user_id, joined_at, last_read_seq)
+VALUES (
+    sqlc.arg(conversation_id),
+    sqlc.arg(user_id),
+    NOW(),
+    (SELECT last_seq FROM conversations WHERE id = sqlc.arg(conversation_id))
+);
+
+-- name: GetConversationForMember :one
+SELECT conversations.* FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg(id) AND conversation_members.user_id = sqlc.arg(user_id);
+
+-- name: ListConversationsForUser :many
+-- Most recently active first. Messages from users blocked either way are
+-- not counted as unread, since they are not listed either.
+SELECT
+    sqlc.embed(conversations),
+    (
+        SELECT COUNT(*) FROM messages
+        WHERE messages.conversation_id = conversations.id
+        AND messages.seq > conversation_members.last_read_seq
+        AND messages.sender_id <> sqlc.arg(user_id)
+        AND NOT EXISTS (
+            SELECT 1 FROM blocks
+            WHERE (blocks.blocker_id = sqlc.arg(user_id) AND blocks.blocked_id = messages.sender_id)
+            OR (blocks.blocker_id = messages.sender_id AND blocks.blocked_id = sqlc.arg(user_id))
+        )
+    ) AS unread_count
+FROM conversations
+JOIN conversation_members ON conversation_members.conversation_id = conversations.id
+WHERE conversation_members.user_id = sqlc.arg(user_id)
+AND (conversations.updated_at, conversations.id) < (sqlc.arg(before_updated_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY conversations.updated_at DESC, conversations.id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListConversationMembers :many
+SELECT conversation_members.conversation_id, users.id, users.handle
+FROM conversation_members
+JOIN users ON users.id = conversation_members.user_id
+WHERE conversation_members.conversation_id = ANY(sqlc.arg(conversation_ids)::uuid[])
+ORDER BY conversation_members.joined_at, users.id;
+
+-- name: AdvanceConversation :one
+-- Returns the seq of a new message. The row lock it takes is held until
+-- the message commits, so messages commit in seq order.
+UPDATE conversations
+SET updated_at = NOW(),
+last_seq = last_seq + 1
+WHERE id = $1
+RETURNING last_seq;
+
+-- name: MarkConversationRead :exec
+-- Messages still being sent have a seq above the committed last_seq, so
+-- they stay unread.
+UPDATE conversation_members
+SET last_read_seq = GREATEST(
+    last_read_seq,
+    (SELECT last_seq FROM conversations WHERE conversations.id = sqlc.arg(conversation_id))
+)
+WHERE conversation_id = sqlc.arg(conversation_id) AND user_id = sqlc.arg(user_id);
\ No newline at end of file
@@ -0,0 +1,65 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, in_reply_to, kind, reference_chirp_id, publish_at, media_ids)
+VALUES (
+    gen_random_uuid (),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: GetDraftByID :one
+SELECT * FROM drafts WHERE id = $1;
+
+-- name: LockDraft :one
+SELECT * FROM drafts WHERE id = $1 FOR UPDATE;
+
+-- name: ListDraftsByUser :many
+SELECT * FROM drafts
+WHERE user_id = sqlc.arg(user_id)
+AND (created_at, id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $2,
+in_reply_to = $3,
+kind = $4,
+reference_chirp_id = $5,
+publish_at = $6,
+media_ids = $7,
+retry_at = NULL,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDraft :exec
+DELETE FROM drafts WHERE id = $1;
+
+-- name: ClaimDueDraft :one
+-- Locks the oldest due draft so that only one instance publishes it.
+-- Rows already locked by another instance are skipped, not waited for,
+-- as are drafts waiting to be retried.
+SELECT * FROM drafts
+WHERE publish_at <= NOW()
+AND (retry_at IS NULL OR retry_at <= NOW())
+ORDER BY publish_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: UnscheduleDraft :exec
+UPDATE drafts
+SET publish_at = NULL,
+retry_at = NULL,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeferDraft :exec
+UPDATE drafts SET retry_at = $2 WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,40 @@
+-- name: CreateFollow :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollow :exec
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: ListFollowers :many
+SELECT users.id, users.handle, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = sqlc.arg(user_id)
+AND (follows.created_at, follows.follower_id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY follows.created_at DESC, follows.follower_id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListFollowing :many
+SELECT users.id, users.handle, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = sqlc.arg(user_id)
+AND (follows.created_at, follows.followee_id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY follows.created_at DESC, follows.followee_id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: GetTimeline :many
+SELECT * FROM chirps
+WHERE deleted_at IS NULL
+AND (
+    user_id = sqlc.arg(user_id)
+    OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg(user_id))
+)
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(user_id) AND hidden_users.hidden_id = chirps.user_id
+)
+AND (created_at, id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_rows);
\ No newline at end of file
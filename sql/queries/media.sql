@@ -0,0 +1,76 @@
+-- name: CreateMedia :one
+INSERT INTO media (id, created_at, user_id, blob_key, content_type, width, height, size_bytes)
+VALUES (
+    gen_random_uuid (),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: GetMediaByIDs :many
+SELECT * FROM media WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: CreateChirpMedia :exec
+INSERT INTO chirp_media (chirp_id, media_id, position)
+VALUES ($1, $2, $3);
+
+-- name: ListChirpMediaByChirpIDs :many
+SELECT chirp_media.chirp_id, sqlc.embed(media)
+FROM chirp_media
+JOIN media ON media.id = chirp_media.media_id
+WHERE chirp_media.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_media.chirp_id, chirp_media.position;
+
+-- name: GetMediaByID :one
+SELECT * FROM media WHERE id = $1;
+
+-- name: ClaimMedia :one
+-- Takes media for processing unless it is done or another worker claimed
+-- it after stale_before. A worker that dies mid-way loses its claim once
+-- it goes stale.
+UPDATE media
+SET claimed_at = NOW()
+WHERE id = sqlc.arg(id)
+AND processed_at IS NULL
+AND (claimed_at IS NULL OR claimed_at < sqlc.arg(stale_before)::timestamp)
+RETURNING *;
+
+-- name: ListUnprocessedMediaIDs :many
+SELECT id FROM media
+WHERE processed_at IS NULL
+AND created_at < sqlc.arg(created_before)::timestamp
+AND (claimed_at IS NULL OR claimed_at < sqlc.arg(stale_before)::timestamp)
+ORDER BY created_at
+LIMIT sqlc.arg(max_rows);
+
+-- name: MarkMediaProcessed :exec
+UPDATE media SET processed_at = NOW() WHERE id = $1;
+
+-- name: UpsertMediaVariant :exec
+INSERT INTO media_variants (media_id, name, created_at, blob_key, content_type, width, height, blurhash)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+ON CONFLICT (media_id, name) DO UPDATE
+SET blob_key = EXCLUDED.blob_key,
+content_type = EXCLUDED.content_type,
+width = EXCLUDED.width,
+height = EXCLUDED.height,
+blurhash = EXCLUDED.blurhash;
+
+-- name: ListMediaVariantsByMediaIDs :many
+SELECT * FROM media_variants
+WHERE media_id = ANY(sqlc.arg(media_ids)::uuid[])
+ORDER BY media_id, width;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- name: CreateMessage :one
+INSERT INTO messages (id, conversation_id, sender_id, created_at, body, seq)
+VALUES (
+    gen_random_uuid (),
+    $1,
+    $2,
+    NOW(),
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: ListMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg(conversation_id)
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocks.blocker_id = sqlc.arg(viewer_id) AND blocks.blocked_id = messages.sender_id)
+    OR (blocks.blocker_id = messages.sender_id AND blocks.blocked_id = sqlc.arg(viewer_id))
+)
+AND (created_at, id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_rows);
\ No newline at end of file
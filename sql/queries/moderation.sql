@@ -0,0 +1,66 @@
+-- name: ListModerationRules :many
+SELECT * FROM moderation_rules ORDER BY created_at, id;
+
+-- name: CreateChirpFlag :exec
+INSERT INTO chirp_flags (id, chirp_id, created_at, source, reason)
+VALUES (
+    gen_random_uuid (),
+    $1,
+    NOW(),
+    $2,
+    $3
+);
+
+-- name: CreateChirpReport :one
+INSERT INTO chirp_flags (id, chirp_id, created_at, source, reason, reporter_id, details)
+VALUES (
+    gen_random_uuid (),
+    $1,
+    NOW(),
+    'report',
+    sqlc.arg(reason),
+    sqlc.arg(reporter_id)::uuid,
+    sqlc.arg(details)
+)
+RETURNING *;
+
+-- name: ListOpenFlaggedChirps :many
+SELECT chirp_id, MIN(created_at)::timestamp AS first_flagged_at
+FROM chirp_flags
+WHERE resolved_at IS NULL
+GROUP BY chirp_id
+HAVING (MIN(created_at), chirp_id) > (sqlc.arg(after_created_at)::timestamp, sqlc.arg(after_id)::uuid)
+ORDER BY first_flagged_at, chirp_id
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListOpenFlagsByChirpIDs :many
+SELECT * FROM chirp_flags
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]) AND resolved_at IS NULL
+ORDER BY created_at, id;
+
+-- name: ResolveChirpFlags :execrows
+UPDATE chirp_flags
+SET resolved_at = NOW(),
+resolved_by = sqlc.arg(resolved_by)::uuid,
+resolution = sqlc.arg(resolution)::text
+WHERE chirp_id = sqlc.arg(chirp_id) AND resolved_at IS NULL;
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, actor_id, action, chirp_id, target_user_id, note, flags_resolved)
+VALUES (
+    gen_random_uuid (),
+    NOW(),
+    sqlc.arg(actor_id)::uuid,
+    sqlc.arg(action),
+    sqlc.arg(chirp_id),
+    sqlc.arg(target_user_id),
+    sqlc.arg(note),
+    sqlc.arg(flags_resolved)
+)
+RETURNING *;
+
+-- name: ListModerationActions :many
+SELECT * FROM moderation_actions
+WHERE (created_at, id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_rows);
\ No newline at end of file
@@ -0,0 +1,109 @@
+-- name: CreateNotification :exec
+-- Does nothing when the recipient opted out of the type, or already has
+-- the same event.
+INSERT INTO notifications (id, created_at, recipient_id, actor_id, type, chirp_id, group_key)
+SELECT
+    gen_random_uuid (),
+    NOW(),
+    sqlc.arg(recipient_id)::uuid,
+    sqlc.arg(actor_id)::uuid,
+    sqlc.arg(type)::text,
+    sqlc.narg(chirp_id)::uuid,
+    sqlc.arg(group_key)::text
+WHERE NOT EXISTS (
+    SELECT 1 FROM notification_opt_outs
+    WHERE notification_opt_outs.user_id = sqlc.arg(recipient_id)::uuid
+    AND notification_opt_outs.type = sqlc.arg(type)::text
+)
+ON CONFLICT DO NOTHING;
+
+-- name: ListNotificationGroups :many
+-- One row per group, most recent activity first. Unread events are grouped
+-- apart from read ones, so new activity shows up as a fresh entry. Events
+-- by users the recipient blocked or muted, or about deleted chirps, are
+-- left out. A group is identified by its newest event, so pages walk the
+-- recipient's events newest first and keep those with no newer event in
+-- their group. Only the newest max_group_events events of a group since
+-- since are counted.
+SELECT
+    notifications.id,
+    notifications.created_at,
+    notifications.type,
+    notifications.group_key,
+    notifications.chirp_id,
+    (notifications.read_at IS NULL)::boolean AS unread,
+    group_events.actor_count,
+    group_events.actor_ids::uuid[] AS actor_ids
+FROM notifications
+CROSS JOIN LATERAL (
+    SELECT
+        COUNT(*) AS actor_count,
+        (ARRAY_AGG(actor_id ORDER BY created_at DESC, id DESC))[1:sqlc.arg(max_actors)::int] AS actor_ids
+    FROM (
+        SELECT event.id, event.created_at, event.actor_id FROM notifications AS event
+        WHERE event.recipient_id = notifications.recipient_id
+        AND event.group_key = notifications.group_key
+        AND (event.read_at IS NULL) = (notifications.read_at IS NULL)
+        AND event.created_at > sqlc.arg(since)::timestamp
+        AND NOT EXISTS (
+            SELECT 1 FROM hidden_users
+            WHERE hidden_users.viewer_id = sqlc.arg(user_id) AND hidden_users.hidden_id = event.actor_id
+        )
+        ORDER BY event.created_at DESC, event.id DESC
+        LIMIT sqlc.arg(max_group_events)
+    ) AS events
+) AS group_events
+WHERE notifications.recipient_id = sqlc.arg(user_id)
+AND notifications.created_at > sqlc.arg(since)::timestamp
+AND (notifications.created_at, notifications.id) < (sqlc.arg(before_created_at)::timestamp, sqlc.arg(before_id)::uuid)
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(user_id) AND hidden_users.hidden_id = notifications.actor_id
+)
+AND (
+    notifications.chirp_id IS NULL
+    OR EXISTS (SELECT 1 FROM chirps WHERE chirps.id = notifications.chirp_id AND chirps.deleted_at IS NULL)
+)
+AND NOT EXISTS (
+    SELECT 1 FROM notifications AS newer
+    WHERE newer.recipient_id = notifications.recipient_id
+    AND newer.group_key = notifications.group_key
+    AND (newer.read_at IS NULL) = (notifications.read_at IS NULL)
+    AND (newer.created_at, newer.id) > (notifications.created_at, notifications.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM hidden_users
+        WHERE hidden_users.viewer_id = sqlc.arg(user_id) AND hidden_users.hidden_id = newer.actor_id
+    )
+)
+ORDER BY notifications.created_at DESC, notifications.id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: CountUnreadNotificationGroups :one
+-- Counts the groups ListNotificationGroups lists as unread.
+SELECT COUNT(DISTINCT group_key) FROM notifications
+WHERE recipient_id = sqlc.arg(user_id)
+AND read_at IS NULL
+AND created_at > sqlc.arg(since)::timestamp
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(user_id) AND hidden_users.hidden_id = notifications.actor_id
+)
+AND (
+    chirp_id IS NULL
+    OR EXISTS (SELECT 1 FROM chirps WHERE chirps.id = notifications.chirp_id AND chirps.deleted_at IS NULL)
+);
+
+-- name: MarkNotificationsRead :exec
+UPDATE notifications SET read_at = NOW()
+WHERE recipient_id = $1 AND read_at IS NULL;
+
+-- name: ListNotificationOptOuts :many
+SELECT type FROM notification_opt_outs WHERE user_id = $1;
+
+-- name: CreateNotificationOptOut :exec
+INSERT INTO notification_opt_outs (user_id, type, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteNotificationOptOut :exec
+DELETE FROM notification_opt_outs WHERE user_id = $1 AND type = $2;
\ No newline at end of file
@@ -0,0 +1,32 @@
+-- name: CountPinnedChirps :one
+-- Pins of deleted chirps do not use up a slot.
+SELECT COUNT(*) FROM pinned_chirps
+JOIN chirps ON chirps.id = pinned_chirps.chirp_id
+WHERE pinned_chirps.user_id = sqlc.arg(user_id)
+AND pinned_chirps.chirp_id <> sqlc.arg(except_chirp_id)
+AND chirps.deleted_at IS NULL;
+
+-- name: CreatePinnedChirp :exec
+INSERT INTO pinned_chirps (user_id, chirp_id, created_at, position)
+VALUES (
+    sqlc.arg(user_id),
+    sqlc.arg(chirp_id),
+    NOW(),
+    (SELECT COALESCE(MAX(position) + 1, 0) FROM pinned_chirps WHERE user_id = sqlc.arg(user_id))
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePinnedChirp :exec
+DELETE FROM pinned_chirps WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: ListPinnedChirps :many
+SELECT chirps.* FROM pinned_chirps
+JOIN chirps ON chirps.id = pinned_chirps.chirp_id
+WHERE pinned_chirps.user_id = sqlc.arg(user_id)
+AND chirps.deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM hidden_users
+    WHERE hidden_users.viewer_id = sqlc.arg(viewer_id) AND hidden_users.hidden_id = chirps.user_id
+)
+ORDER BY pinned_chirps.position, pinned_chirps.created_at
+LIMIT sqlc.arg(max_rows);
\ No newline at end of file
@@ -0,0 +1,53 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, multiple_choice, expires_at)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3
+);
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (id, chirp_id, position, text)
+VALUES (
+    gen_random_uuid (),
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetPollsByChirpIDs :many
+SELECT polls.*, (
+    SELECT COUNT(*) FROM poll_ballots WHERE poll_ballots.chirp_id = polls.chirp_id
+) AS voters
+FROM polls
+WHERE polls.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: ListPollOptionsByChirpIDs :many
+SELECT poll_options.*, COUNT(poll_ballot_choices.option_id) AS votes
+FROM poll_options
+LEFT JOIN poll_ballot_choices ON poll_ballot_choices.option_id = poll_options.id
+WHERE poll_options.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+GROUP BY poll_options.id
+ORDER BY poll_options.chirp_id, poll_options.position;
+
+-- name: ListPollChoicesByUser :many
+SELECT chirp_id, option_id FROM poll_ballot_choices
+WHERE user_id = sqlc.arg(user_id)
+AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: CreatePollBallot :exec
+INSERT INTO poll_ballots (chirp_id, user_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+);
+
+-- name: CreatePollBallotChoice :exec
+INSERT INTO poll_ballot_choices (chirp_id, user_id, option_id)
+VALUES (
+    $1,
+    $2,
+    $3
+);
\ No newline at end of file
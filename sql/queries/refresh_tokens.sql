@@ -20,4 +20,10 @@ updated_at = NOW()
 WHERE token = $1;
 
 -- name: GetRefreshToken :one
-SELECT * FROM refresh_tokens WHERE token = $1;
\ No newline at end of file
+SELECT * FROM refresh_tokens WHERE token = $1;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(),
+updated_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL;
\ No newline at end of file
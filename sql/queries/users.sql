@@ -1,16 +1,17 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid (),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
--- name: DeleteAllUsers :exec
-DELETE FROM users;
+-- name: DeleteNonAdminUsers :exec
+DELETE FROM users WHERE role <> 'admin';
 
 -- name: GetUserByEmail :one
 SELECT * FROM users WHERE email = $1;
@@ -24,4 +25,54 @@ WHERE id = $1;
 -- name: UpdateChirpyRed :exec
 UPDATE users
 SET is_chirpy_red = $2
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: GetUsersByHandles :many
+SELECT * FROM users WHERE lower(handle) = ANY(sqlc.arg(handles)::text[]);
+
+-- name: GetUserByID :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: GetUserByHandle :one
+SELECT * FROM users WHERE lower(handle) = lower(sqlc.arg(handle));
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = $2,
+display_name = $3,
+bio = $4,
+avatar_url = $5,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserProfileCounts :one
+SELECT
+    (SELECT COUNT(*) FROM chirps WHERE chirps.user_id = sqlc.arg(user_id) AND chirps.deleted_at IS NULL) AS chirp_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.followee_id = sqlc.arg(user_id)) AS follower_count,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = sqlc.arg(user_id)) AS following_count;
+
+-- name: GetUsersByIDs :many
+SELECT * FROM users WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: PromoteUsersByID :execrows
+UPDATE users
+SET role = 'admin',
+updated_at = NOW()
+WHERE id = ANY(sqlc.arg(ids)::uuid[]) AND role <> 'admin';
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2,
+updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: BanUser :exec
+UPDATE users
+SET banned_at = NOW(),
+updated_at = NOW()
+WHERE id = $1 AND banned_at IS NULL;
+
+-- name: LockUser :one
+SELECT * FROM users WHERE id = $1 FOR UPDATE;
\ No newline at end of file
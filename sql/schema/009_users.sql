@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN handle TEXT;
+CREATE UNIQUE INDEX users_handle_idx ON users (lower(handle));
+
+-- +goose Down
+DROP INDEX users_handle_idx;
+ALTER TABLE users DROP COLUMN handle;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN kind TEXT NOT NULL DEFAULT 'chirp'
+    CHECK (kind IN ('chirp', 'rechirp', 'quote'));
+ALTER TABLE chirps ADD COLUMN reference_chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+CREATE INDEX chirps_reference_chirp_id_idx ON chirps (reference_chirp_id);
+CREATE UNIQUE INDEX chirps_user_id_rechirp_idx ON chirps (user_id, reference_chirp_id)
+    WHERE kind = 'rechirp';
+
+-- +goose Down
+DROP INDEX chirps_user_id_rechirp_idx;
+DROP INDEX chirps_reference_chirp_id_idx;
+ALTER TABLE chirps DROP COLUMN reference_chirp_id;
+ALTER TABLE chirps DROP COLUMN kind;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+-- hidden_users lists, for each viewer, the users whose content they must
+-- not see: blocks hide both users from each other, mutes only hide the
+-- muted user from the muter. Every chirp query filters through it.
+CREATE VIEW hidden_users AS
+SELECT blocker_id AS viewer_id, blocked_id AS hidden_id FROM blocks
+UNION ALL
+SELECT blocked_id AS viewer_id, blocker_id AS hidden_id FROM blocks
+UNION ALL
+SELECT muter_id AS viewer_id, muted_id AS hidden_id FROM mutes;
+
+-- +goose Down
+DROP VIEW hidden_users;
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE drafts (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    in_reply_to UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    kind TEXT NOT NULL DEFAULT 'chirp'
+        CHECK (kind IN ('chirp', 'rechirp', 'quote')),
+    reference_chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    publish_at TIMESTAMP
+);
+
+CREATE INDEX drafts_user_id_created_at_idx ON drafts (user_id, created_at, id);
+CREATE INDEX drafts_publish_at_idx ON drafts (publish_at) WHERE publish_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE drafts;
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE media (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blob_key TEXT NOT NULL UNIQUE,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size_bytes BIGINT NOT NULL
+);
+
+CREATE INDEX media_user_id_idx ON media (user_id);
+
+-- +goose Down
+DROP TABLE media;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE drafts ADD COLUMN media_ids UUID[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE drafts DROP COLUMN media_ids;
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE media ADD COLUMN claimed_at TIMESTAMP;
+ALTER TABLE media ADD COLUMN processed_at TIMESTAMP;
+CREATE INDEX media_unprocessed_idx ON media (created_at) WHERE processed_at IS NULL;
+
+-- +goose Down
+DROP INDEX media_unprocessed_idx;
+ALTER TABLE media DROP COLUMN processed_at;
+ALTER TABLE media DROP COLUMN claimed_at;
\ No newline at end of file
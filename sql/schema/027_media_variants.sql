@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE media_variants (
+    media_id UUID NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    blob_key TEXT NOT NULL UNIQUE,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    blurhash TEXT NOT NULL,
+    PRIMARY KEY (media_id, name)
+);
+
+-- +goose Down
+DROP TABLE media_variants;
\ No newline at end of file
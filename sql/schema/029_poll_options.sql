@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE poll_options (
+    id UUID PRIMARY KEY,
+    chirp_id UUID NOT NULL REFERENCES polls(chirp_id) ON DELETE CASCADE,
+    position SMALLINT NOT NULL CHECK (position BETWEEN 0 AND 3),
+    text TEXT NOT NULL,
+    UNIQUE (chirp_id, position),
+    UNIQUE (chirp_id, id)
+);
+
+-- +goose Down
+DROP TABLE poll_options;
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE poll_ballots (
+    chirp_id UUID NOT NULL REFERENCES polls(chirp_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+-- +goose Down
+DROP TABLE poll_ballots;
\ No newline at end of file
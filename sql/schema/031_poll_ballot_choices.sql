@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE poll_ballot_choices (
+    chirp_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    option_id UUID NOT NULL,
+    PRIMARY KEY (chirp_id, user_id, option_id),
+    FOREIGN KEY (chirp_id, user_id) REFERENCES poll_ballots(chirp_id, user_id) ON DELETE CASCADE,
+    FOREIGN KEY (chirp_id, option_id) REFERENCES poll_options(chirp_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX poll_ballot_choices_option_id_idx ON poll_ballot_choices (option_id);
+
+-- +goose Down
+DROP TABLE poll_ballot_choices;
\ No newline at end of file
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE moderation_rules (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('word', 'pattern')),
+    value TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('mask', 'reject', 'review')),
+    UNIQUE (kind, value)
+);
+
+INSERT INTO moderation_rules (id, created_at, kind, value, action)
+VALUES
+    (gen_random_uuid (), NOW(), 'word', 'kerfuffle', 'mask'),
+    (gen_random_uuid (), NOW(), 'word', 'sharbert', 'mask'),
+    (gen_random_uuid (), NOW(), 'word', 'fornax', 'mask');
+
+-- +goose Down
+DROP TABLE moderation_rules;
\ No newline at end of file
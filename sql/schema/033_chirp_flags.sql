@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE chirp_flags (
+    id UUID PRIMARY KEY,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('filter')),
+    reason TEXT NOT NULL,
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX chirp_flags_unresolved_idx ON chirp_flags (created_at, id) WHERE resolved_at IS NULL;
+
+-- +goose Down
+DROP TABLE chirp_flags;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin'));
+ALTER TABLE users ADD COLUMN banned_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN banned_at;
+ALTER TABLE users DROP COLUMN role;
\ No newline at end of file
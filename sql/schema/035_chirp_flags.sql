@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE chirp_flags DROP CONSTRAINT chirp_flags_source_check;
+ALTER TABLE chirp_flags ADD CONSTRAINT chirp_flags_source_check CHECK (source IN ('filter', 'report'));
+ALTER TABLE chirp_flags ADD COLUMN reporter_id UUID REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE chirp_flags ADD COLUMN details TEXT NOT NULL DEFAULT '';
+ALTER TABLE chirp_flags ADD COLUMN resolved_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE chirp_flags ADD COLUMN resolution TEXT;
+
+-- A user has at most one open report per chirp. Filter flags have no
+-- reporter and NULLs never collide, so they are not limited.
+CREATE UNIQUE INDEX chirp_flags_open_report_idx ON chirp_flags (chirp_id, reporter_id) WHERE resolved_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirp_flags_open_report_idx;
+ALTER TABLE chirp_flags DROP COLUMN resolution;
+ALTER TABLE chirp_flags DROP COLUMN resolved_by;
+ALTER TABLE chirp_flags DROP COLUMN details;
+ALTER TABLE chirp_flags DROP COLUMN reporter_id;
+DELETE FROM chirp_flags WHERE source = 'report';
+ALTER TABLE chirp_flags DROP CONSTRAINT chirp_flags_source_check;
+ALTER TABLE chirp_flags ADD CONSTRAINT chirp_flags_source_check CHECK (source IN ('filter'));
\ No newline at end of file
@@ -0,0 +1,19 @@
+-- +goose Up
+-- moderation_actions is the audit log of what staff did. chirp_id and
+-- target_user_id are deliberately not foreign keys, so entries outlive the
+-- chirps and users they are about.
+CREATE TABLE moderation_actions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL CHECK (action IN ('dismiss', 'hide', 'delete', 'ban', 'set_role')),
+    chirp_id UUID,
+    target_user_id UUID,
+    note TEXT NOT NULL DEFAULT '',
+    flags_resolved INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX moderation_actions_created_at_idx ON moderation_actions (created_at, id);
+
+-- +goose Down
+DROP TABLE moderation_actions;
\ No newline at end of file
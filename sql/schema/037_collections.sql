@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE collections (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL
+);
+
+CREATE UNIQUE INDEX collections_user_id_name_idx ON collections (user_id, lower(name));
+
+-- +goose Down
+DROP TABLE collections;
\ No newline at end of file
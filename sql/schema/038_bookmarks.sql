@@ -0,0 +1,14 @@
+-- +goose Up
+-- Deleting a collection keeps its bookmarks, outside any collection.
+CREATE TABLE bookmarks (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    collection_id UUID REFERENCES collections(id) ON DELETE SET NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX bookmarks_user_id_created_at_idx ON bookmarks (user_id, created_at, chirp_id);
+
+-- +goose Down
+DROP TABLE bookmarks;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- +goose Up
+-- direct_key identifies a one-to-one conversation by its two members, so
+-- each pair of users has at most one. It is NULL for group conversations.
+CREATE TABLE conversations (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    direct_key TEXT UNIQUE
+);
+
+-- +goose Down
+DROP TABLE conversations;
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- +goose Up
+-- notifications holds one row per event. Events sharing a group_key, such
+-- as every like of one chirp, are listed as a single entry. The unique
+-- index stops the same actor from notifying about the same thing twice.
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    recipient_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('like', 'reply', 'follow', 'mention')),
+    chirp_id UUID REFERENCES chirps(id) ON DELETE CASCADE,
+    group_key TEXT NOT NULL,
+    read_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX notifications_event_idx ON notifications (recipient_id, group_key, actor_id);
+CREATE INDEX notifications_recipient_id_created_at_idx ON notifications (recipient_id, created_at);
+
+-- +goose Down
+DROP TABLE notifications;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- +goose Up
+-- A row here stops user_id from being notified about events of type.
+CREATE TABLE notification_opt_outs (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('like', 'reply', 'follow', 'mention')),
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, type)
+);
+
+-- +goose Down
+DROP TABLE notification_opt_outs;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- +goose Up
+-- A deleted rechirp no longer stops the user from rechirping the same
+-- chirp again.
+DROP INDEX chirps_user_id_rechirp_idx;
+CREATE UNIQUE INDEX chirps_user_id_rechirp_idx ON chirps (user_id, reference_chirp_id)
+    WHERE kind = 'rechirp' AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_id_rechirp_idx;
+CREATE UNIQUE INDEX chirps_user_id_rechirp_idx ON chirps (user_id, reference_chirp_id)
+    WHERE kind = 'rechirp';
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- +goose Up
+-- created_xid is the transaction that created the chirp. Comparing it
+-- with a snapshot tells whether the chirp had committed when the snapshot
+-- was taken, which created_at cannot: it is set when the transaction
+-- starts, not when it commits.
+ALTER TABLE chirps ADD COLUMN created_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+CREATE INDEX chirps_created_xid_idx ON chirps (created_xid);
+
+-- +goose Down
+DROP INDEX chirps_created_xid_idx;
+ALTER TABLE chirps DROP COLUMN created_xid;
\ No newline at end of file
@@ -0,0 +1,50 @@
+-- +goose Up
+-- Messages are numbered per conversation with last_seq, which sending a
+-- message increments while holding the conversation's row lock. seq
+-- therefore follows commit order, so a member's last_read_seq covers
+-- exactly the messages committed when they read the conversation.
+ALTER TABLE conversations ADD COLUMN last_seq BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN seq BIGINT;
+ALTER TABLE conversation_members ADD COLUMN last_read_seq BIGINT NOT NULL DEFAULT 0;
+
+UPDATE messages SET seq = numbered.seq
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY conversation_id ORDER BY created_at, id) AS seq
+    FROM messages
+) AS numbered
+WHERE messages.id = numbered.id;
+
+UPDATE conversations SET last_seq = COALESCE(
+    (SELECT MAX(seq) FROM messages WHERE messages.conversation_id = conversations.id),
+    0
+);
+
+UPDATE conversation_members SET last_read_seq = COALESCE(
+    (
+        SELECT MAX(seq) FROM messages
+        WHERE messages.conversation_id = conversation_members.conversation_id
+        AND messages.created_at <= conversation_members.last_read_at
+    ),
+    0
+);
+
+ALTER TABLE messages ALTER COLUMN seq SET NOT NULL;
+CREATE UNIQUE INDEX messages_conversation_id_seq_idx ON messages (conversation_id, seq);
+ALTER TABLE conversation_members DROP COLUMN last_read_at;
+
+-- +goose Down
+ALTER TABLE conversation_members ADD COLUMN last_read_at TIMESTAMP NOT NULL DEFAULT NOW();
+UPDATE conversation_members SET last_read_at = COALESCE(
+    (
+        SELECT MAX(created_at) FROM messages
+        WHERE messages.conversation_id = conversation_members.conversation_id
+        AND messages.seq <= conversation_members.last_read_seq
+    ),
+    conversation_members.joined_at
+);
+ALTER TABLE conversation_members ALTER COLUMN last_read_at DROP DEFAULT;
+
+DROP INDEX messages_conversation_id_seq_idx;
+ALTER TABLE conversation_members DROP COLUMN last_read_seq;
+ALTER TABLE messages DROP COLUMN seq;
+ALTER TABLE conversations DROP COLUMN last_seq;
\ No newline at end of file
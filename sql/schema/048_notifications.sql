@@ -0,0 +1,9 @@
+-- +goose Up
+-- notifications_group_idx finds the other events of a notification's
+-- group, and notifications_unread_idx a recipient's unread events.
+CREATE INDEX notifications_group_idx ON notifications (recipient_id, group_key, created_at, id);
+CREATE INDEX notifications_unread_idx ON notifications (recipient_id, created_at) WHERE read_at IS NULL;
+
+-- +goose Down
+DROP INDEX notifications_unread_idx;
+DROP INDEX notifications_group_idx;
\ No newline at end of file
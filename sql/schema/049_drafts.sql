@@ -0,0 +1,17 @@
+-- +goose Up
+-- A draft replying to or quoting a chirp that is purged goes with it,
+-- rather than losing the reference and publishing as something else.
+ALTER TABLE drafts DROP CONSTRAINT drafts_in_reply_to_fkey;
+ALTER TABLE drafts ADD CONSTRAINT drafts_in_reply_to_fkey
+    FOREIGN KEY (in_reply_to) REFERENCES chirps(id) ON DELETE CASCADE;
+ALTER TABLE drafts DROP CONSTRAINT drafts_reference_chirp_id_fkey;
+ALTER TABLE drafts ADD CONSTRAINT drafts_reference_chirp_id_fkey
+    FOREIGN KEY (reference_chirp_id) REFERENCES chirps(id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE drafts DROP CONSTRAINT drafts_reference_chirp_id_fkey;
+ALTER TABLE drafts ADD CONSTRAINT drafts_reference_chirp_id_fkey
+    FOREIGN KEY (reference_chirp_id) REFERENCES chirps(id) ON DELETE SET NULL;
+ALTER TABLE drafts DROP CONSTRAINT drafts_in_reply_to_fkey;
+ALTER TABLE drafts ADD CONSTRAINT drafts_in_reply_to_fkey
+    FOREIGN KEY (in_reply_to) REFERENCES chirps(id) ON DELETE SET NULL;
\ No newline at end of file
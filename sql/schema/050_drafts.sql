@@ -0,0 +1,7 @@
+-- +goose Up
+-- retry_at holds back a due draft that failed to publish, so it does not
+-- stop the drafts due after it from going out.
+ALTER TABLE drafts ADD COLUMN retry_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE drafts DROP COLUMN retry_at;
\ No newline at end of file
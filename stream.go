@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// chirpEventsChannel must match the channel NotifyChirpEvent sends on.
+	chirpEventsChannel = "chirp_events"
+
+	chirpEventCreated = "chirp"
+	chirpEventDeleted = "delete"
+
+	// chirpEventReset tells a resuming client that it missed too many
+	// chirps to replay, and should reload the list instead.
+	chirpEventReset = "reset"
+
+	streamHeartbeatInterval = 15 * time.Second
+	// streamBuffer is how many events a stream may fall behind by before
+	// it is dropped. The client then reconnects and catches up through
+	// Last-Event-ID.
+	streamBuffer = 64
+	// streamReplayLimit caps how many missed chirps are sent to a
+	// resuming client.
+	streamReplayLimit = 500
+)
+
+var snapshotPattern = regexp.MustCompile(`^[0-9]+:[0-9]+:([0-9]+(,[0-9]+)*)?$`)
+
+// chirpEvent is the payload of a notification on chirpEventsChannel. It
+// only carries IDs, as notification payloads are limited to 8000 bytes.
+// Snapshot is the database snapshot when the event was published.
+type chirpEvent struct {
+	Type     string    `json:"type"`
+	ChirpID  uuid.UUID `json:"chirp_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Snapshot string    `json:"snapshot"`
+}
+
+// streamPosition is what a stream's event IDs encode: every chirp whose
+// transaction had committed by Snapshot has been sent, as has ChirpID,
+// whose own transaction had not. A chirp's created_at cannot serve here,
+// since chirps do not commit in created_at order.
+type streamPosition struct {
+	Snapshot string    `json:"s"`
+	ChirpID  uuid.UUID `json:"c"`
+}
+
+func (p streamPosition) encode() string {
+	data, _ := json.Marshal(p)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeStreamPosition(s string) (streamPosition, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return streamPosition{}, err
+	}
+	p := streamPosition{}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return streamPosition{}, err
+	}
+	if !snapshotPattern.MatchString(p.Snapshot) {
+		return streamPosition{}, errors.New("invalid snapshot")
+	}
+	return p, nil
+}
+
+// streamEvent is a chirp event as handed to this instance's streams.
+// Chirp is only set for created chirps.
+type streamEvent struct {
+	chirpEvent
+	Chirp database.Chirp
+}
+
+// publishChirpEvent tells every Chirpy instance that chirp was created,
+// restored or deleted. Sent through a transaction, the event goes out on
+// commit, so streams never see a chirp that was rolled back.
+func publishChirpEvent(ctx context.Context, qtx *database.Queries, eventType string, chirp database.Chirp) error {
+	return qtx.NotifyChirpEvent(ctx, database.NotifyChirpEventParams{
+		Type:    eventType,
+		ChirpID: chirp.ID,
+		UserID:  chirp.UserID,
+	})
+}
+
+// runChirpStream listens for chirp events from every Chirpy instance
+// sharing the database and hands them to this instance's streams, until
+// ctx is cancelled.
+func (cfg *apiConfig) runChirpStream(ctx context.Context) {
+	listener := pq.NewListener(cfg.dbURL, time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Error listening for chirp events: %s", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(chirpEventsChannel); err != nil {
+		log.Printf("Error listening for chirp events: %s", err)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			if n == nil {
+				// The connection was re-established and events sent in
+				// the meantime are lost. Dropping every stream makes the
+				// clients reconnect and replay what they missed.
+				cfg.chirpStream.DropAll()
+				continue
+			}
+			cfg.dispatchChirpEvent(ctx, n.Extra)
+		case <-time.After(90 * time.Second):
+			// Check the connection is still alive when it has been
+			// quiet for a while.
+			go listener.Ping()
+		}
+	}
+}
+
+// dispatchChirpEvent loads the chirp a notification is about, once for
+// all of this instance's streams, and publishes it to them.
+func (cfg *apiConfig) dispatchChirpEvent(ctx context.Context, payload string) {
+	if cfg.chirpStream.Len() == 0 {
+		return
+	}
+
+	e := chirpEvent{}
+	if err := json.Unmarshal([]byte(payload), &e); err != nil {
+		log.Printf("Error decoding chirp event: %s", err)
+		return
+	}
+
+	event := streamEvent{chirpEvent: e}
+	if e.Type == chirpEventCreated {
+		chirp, err := cfg.db.GetChirpByID(ctx, e.ChirpID)
+		if err != nil {
+			log.Printf("Error fetching streamed chirp: %s", err)
+			return
+		}
+		if chirp.DeletedAt.Valid {
+			// Deleted again since; its delete event follows.
+			return
+		}
+		event.Chirp = chirp
+	}
+	cfg.chirpStream.Publish(event)
+}
+
+// handleStreamChirps sends new and restored chirps, and the IDs of deleted
+// ones, as server-sent events, optionally only those by author_id. Chirps
+// are filtered for the viewer like GET /api/chirps.
+//
+// A client reconnecting with Last-Event-ID is first sent the chirps
+// created while it was away, oldest first, or a reset event if there are
+// more than streamReplayLimit. Restores and deletions are not replayed. A
+// chirp may rarely be sent twice, so clients should ignore chirp IDs they
+// already have.
+func (cfg *apiConfig) handleStreamChirps(w http.ResponseWriter, req *http.Request) {
+	query := chirpQuery{ViewerID: cfg.viewerID(req)}
+
+	if authorIDStr := req.URL.Query().Get("author_id"); authorIDStr != "" {
+		authorID, err := uuid.Parse(authorIDStr)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "author_id must be a valid UUID",
+			})
+			return
+		}
+		query.AuthorID = uuid.NullUUID{UUID: authorID, Valid: true}
+	}
+
+	var resume *streamPosition
+	if lastEventID := req.Header.Get("Last-Event-ID"); lastEventID != "" {
+		position, err := decodeStreamPosition(lastEventID)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: "Last-Event-ID must be the ID of an event from this stream",
+			})
+			return
+		}
+		resume = &position
+	}
+
+	// Subscribe before replaying, so chirps created in between are not
+	// missed.
+	events, unsubscribe := cfg.chirpStream.Subscribe(streamBuffer)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+
+	replayed := map[uuid.UUID]bool{}
+	if resume != nil {
+		if err := cfg.replayChirps(req.Context(), w, query, *resume, replayed); err != nil {
+			log.Printf("Error replaying chirps: %s", err)
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-req.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				// Dropped for falling behind or after a lost connection
+				// to the database.
+				return
+			}
+			if query.AuthorID.Valid && e.UserID != query.AuthorID.UUID {
+				continue
+			}
+			switch e.Type {
+			case chirpEventCreated:
+				if replayed[e.ChirpID] {
+					continue
+				}
+				position := streamPosition{Snapshot: e.Snapshot, ChirpID: e.ChirpID}
+				err = cfg.writeChirpEvents(req.Context(), w, query.ViewerID, []database.Chirp{e.Chirp}, position.encode())
+			case chirpEventDeleted:
+				err = writeServerSentEvent(w, "", chirpEventDeleted, chirpSummaryJSON{ID: e.ChirpID, Deleted: true})
+			}
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// replayChirps writes the chirps created since position, recording their
+// IDs in replayed. Their events carry no ID, so a client cut off halfway
+// resumes from position again; the new position follows them on its own.
+// When there are too many chirps to replay, a reset event is sent
+// instead.
+func (cfg *apiConfig) replayChirps(ctx context.Context, w io.Writer, q chirpQuery, position streamPosition, replayed map[uuid.UUID]bool) error {
+	// Repeatable read, so the chirps listed are exactly those visible in
+	// the new snapshot.
+	tx, err := cfg.sqlDB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	snapshot, err := qtx.GetCurrentSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	chirps, err := qtx.ListChirpsCommittedSince(ctx, database.ListChirpsCommittedSinceParams{
+		Snapshot: position.Snapshot,
+		ExceptID: position.ChirpID,
+		AuthorID: q.AuthorID,
+		ViewerID: q.ViewerID,
+		MaxRows:  streamReplayLimit + 1,
+	})
+	if err != nil {
+		return err
+	}
+	next := streamPosition{Snapshot: snapshot}.encode()
+
+	if len(chirps) > streamReplayLimit {
+		return writeServerSentEvent(w, next, chirpEventReset, struct{}{})
+	}
+
+	if err := cfg.writeChirpEvents(ctx, w, q.ViewerID, chirps, ""); err != nil {
+		return err
+	}
+	for _, chirp := range chirps {
+		replayed[chirp.ID] = true
+	}
+
+	// An event with an ID but no data moves the client's Last-Event-ID
+	// without dispatching anything.
+	_, err = fmt.Fprintf(w, "id: %s\n\n", next)
+	return err
+}
+
+// writeChirpEvents writes one event per chirp that viewerID may see, all
+// with the given ID.
+func (cfg *apiConfig) writeChirpEvents(ctx context.Context, w io.Writer, viewerID uuid.UUID, chirps []database.Chirp, id string) error {
+	chirpsJSON, err := cfg.renderChirps(ctx, viewerID, chirps)
+	if err != nil {
+		return err
+	}
+
+	for _, chirp := range chirpsJSON {
+		if err := writeServerSentEvent(w, id, chirpEventCreated, chirp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeServerSentEvent writes data as the JSON payload of an event. An
+// event without an ID leaves the client's Last-Event-ID unchanged.
+func writeServerSentEvent(w io.Writer, id, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
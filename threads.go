@@ -0,0 +1,125 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxThreadDepth   = 50
+	maxThreadReplies = 500
+)
+
+type threadNodeJSON struct {
+	chirpJSON
+	Replies []*threadNodeJSON `json:"replies"`
+}
+
+type threadJSON struct {
+	Ancestors []chirpJSON     `json:"ancestors"`
+	Chirp     *threadNodeJSON `json:"chirp"`
+}
+
+func (cfg *apiConfig) handleGetThread(w http.ResponseWriter, req *http.Request) {
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: "chirpID must be a valid UUID",
+		})
+		return
+	}
+
+	chirp, err := cfg.db.GetChirpByID(req.Context(), chirpID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+
+	ancestors, err := cfg.db.GetChirpAncestors(req.Context(), database.GetChirpAncestorsParams{
+		ChirpID:  chirpID,
+		MaxDepth: maxThreadDepth,
+	})
+	if err != nil {
+		log.Printf("Error fetching chirp ancestors: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	descendants, err := cfg.db.GetChirpDescendants(req.Context(), database.GetChirpDescendantsParams{
+		ChirpID:  chirpID,
+		MaxDepth: maxThreadDepth,
+		MaxRows:  maxThreadReplies,
+	})
+	if err != nil {
+		log.Printf("Error fetching chirp replies: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	viewerID := cfg.viewerID(req)
+	ancestorsJSON, err := cfg.renderChirps(req.Context(), viewerID, ancestors)
+	if err != nil {
+		log.Printf("Error rendering chirps: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	chirpsJSON, err := cfg.renderChirps(req.Context(), viewerID, append([]database.Chirp{chirp}, descendants...))
+	if err != nil {
+		log.Printf("Error rendering chirps: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	// renderChirps drops chirps hidden from the viewer; if that includes
+	// the requested chirp, the thread is not visible at all. Replies under
+	// a hidden chirp are dropped by buildThreadTree.
+	if len(chirpsJSON) == 0 || chirpsJSON[0].ID != chirp.ID {
+		writeJSON(w, http.StatusNotFound, errorJSON{
+			Error: "Chirp not found",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, threadJSON{
+		Ancestors: ancestorsJSON,
+		Chirp:     buildThreadTree(chirpsJSON),
+	})
+}
+
+// buildThreadTree nests replies under their parents. The first chirp is
+// the root; the rest must be ordered so that a parent comes before its
+// replies, which holds because a reply is always created after its parent.
+// Deleted chirps stay in the tree as tombstones.
+func buildThreadTree(chirps []chirpJSON) *threadNodeJSON {
+	root := &threadNodeJSON{chirpJSON: chirps[0], Replies: []*threadNodeJSON{}}
+	nodes := map[uuid.UUID]*threadNodeJSON{root.ID: root}
+
+	for _, c := range chirps[1:] {
+		if c.InReplyTo == nil {
+			continue
+		}
+		parent, ok := nodes[*c.InReplyTo]
+		if !ok {
+			continue
+		}
+		node := &threadNodeJSON{chirpJSON: c, Replies: []*threadNodeJSON{}}
+		parent.Replies = append(parent.Replies, node)
+		nodes[c.ID] = node
+	}
+
+	return root
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/pagination"
+	"github.com/google/uuid"
+)
+
+// timelineSource produces a user's home timeline: their own chirps and
+// those of the accounts they follow, newest first. queryTimeline builds it
+// on every read; a fan-out-on-write cache can implement the same interface
+// without changing the handler or the API.
+type timelineSource interface {
+	Timeline(ctx context.Context, userID uuid.UUID, before pagination.Cursor, limit int) ([]database.Chirp, error)
+}
+
+type queryTimeline struct {
+	db *database.Queries
+}
+
+func (t queryTimeline) Timeline(ctx context.Context, userID uuid.UUID, before pagination.Cursor, limit int) ([]database.Chirp, error) {
+	return t.db.GetTimeline(ctx, database.GetTimelineParams{
+		UserID:          userID,
+		BeforeCreatedAt: before.CreatedAt,
+		BeforeID:        before.ID,
+		MaxRows:         int32(limit),
+	})
+}
+
+func (cfg *apiConfig) handleGetTimeline(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticatedUserID(req)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorJSON{
+			Error: "User not authorized",
+		})
+		return
+	}
+
+	cursor, limit, err := parsePage(req, true)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	chirps, err := cfg.timeline.Timeline(req.Context(), userID, cursor, limit+1)
+	if err != nil {
+		log.Printf("Error fetching timeline: %s", err)
+		writeJSON(w, http.StatusInternalServerError, errorJSON{
+			Error: "Something went wrong",
+		})
+		return
+	}
+
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		last := chirps[limit-1]
+		setNextLink(w, req, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	cfg.writeChirps(w, req, http.StatusOK, chirps)
+}
@@ -1,21 +1,48 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/flames31/Chirpy/internal/auth"
 	"github.com/flames31/Chirpy/internal/database"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,15}$`)
+
+// validateHandle checks that a handle can be mentioned as @handle. "me"
+// is reserved because it names the caller in /api/users/me routes.
+func validateHandle(handle string) error {
+	if !handlePattern.MatchString(handle) {
+		return errors.New("handle must be 1-15 letters, digits or underscores")
+	}
+	if strings.EqualFold(handle, "me") {
+		return errors.New("handle is reserved")
+	}
+	return nil
+}
+
+// isUniqueViolation reports whether err was caused by the given unique
+// constraint or index.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, req *http.Request) {
 	type incoming struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
 
 	type respJSON struct {
@@ -23,7 +50,8 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, req *http.Request
 		CreatedAt   time.Time `json:"created_at"`
 		UpdatedAt   time.Time `json:"updated_at"`
 		Email       string    `json:"email"`
-		IsChirpyRed bool      `json:"is_chirpy_red`
+		IsChirpyRed bool      `json:"is_chirpy_red"`
+		Handle      *string   `json:"handle"`
 	}
 
 	incomingJSON := incoming{}
@@ -35,6 +63,15 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, req *http.Request
 		return
 	}
 
+	if incomingJSON.Handle != "" {
+		if err := validateHandle(incomingJSON.Handle); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorJSON{
+				Error: err.Error(),
+			})
+			return
+		}
+	}
+
 	hashed_password, err := auth.HashPassword(incomingJSON.Password)
 	if err != nil {
 		log.Printf("Error hashing password: %s", err)
@@ -47,7 +84,17 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, req *http.Request
 	user, err := cfg.db.CreateUser(req.Context(), database.CreateUserParams{
 		Email:          incomingJSON.Email,
 		HashedPassword: hashed_password,
+		Handle: sql.NullString{
+			String: incomingJSON.Handle,
+			Valid:  incomingJSON.Handle != "",
+		},
 	})
+	if isUniqueViolation(err, "users_handle_idx") {
+		writeJSON(w, http.StatusConflict, errorJSON{
+			Error: "Handle is already taken",
+		})
+		return
+	}
 	if err != nil {
 		log.Printf("Error creating user: %s", err)
 		writeJSON(w, http.StatusInternalServerError, errorJSON{
@@ -62,9 +109,20 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, req *http.Request
 		UpdatedAt:   user.UpdatedAt,
 		Email:       user.Email,
 		IsChirpyRed: user.IsChirpyRed,
+		Handle:      nullStringPtr(user.Handle),
 	})
 }
 
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// handleReset deletes every user but the admins. It needs both a dev
+// platform and an admin, since either alone is too easy to get wrong, and
+// admins are kept so the endpoint can be used again.
 func (cfg *apiConfig) handleReset(w http.ResponseWriter, req *http.Request) {
 	platform := os.Getenv("PLATFORM")
 	if platform != "dev" {
@@ -74,10 +132,14 @@ func (cfg *apiConfig) handleReset(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	err := cfg.db.DeleteAllUsers(req.Context())
+	if _, ok := cfg.requireAdmin(w, req); !ok {
+		return
+	}
+
+	err := cfg.db.DeleteNonAdminUsers(req.Context())
 
 	if err != nil {
-		log.Printf("Error deleting all users: %s", err)
+		log.Printf("Error deleting users: %s", err)
 		writeJSON(w, http.StatusInternalServerError, errorJSON{
 			Error: "Something went wrong",
 		})
@@ -114,7 +176,7 @@ func (cfg *apiConfig) handleUpdateCredentials(w http.ResponseWriter, req *http.R
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.jwtToken)
+	userID, err := cfg.validateToken(req.Context(), token)
 	if err != nil {
 		writeJSON(w, http.StatusUnauthorized, errorJSON{
 			Error: "User not authorized",
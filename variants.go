@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/flames31/Chirpy/internal/database"
+	"github.com/flames31/Chirpy/internal/media"
+	"github.com/google/uuid"
+)
+
+// mediaVariantSpecs are the sizes generated for every upload, smallest
+// first.
+var mediaVariantSpecs = []media.VariantSpec{
+	{Name: "thumb", MaxSize: 200},
+	{Name: "medium", MaxSize: 800},
+}
+
+const (
+	mediaQueueSize     = 256
+	mediaSweepInterval = time.Minute
+	mediaSweepBatch    = 100
+	// mediaClaimTimeout is how long a worker may hold an upload before
+	// another worker, possibly on another instance, assumes it died.
+	mediaClaimTimeout = 5 * time.Minute
+)
+
+type mediaVariantJSON struct {
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int32  `json:"width"`
+	Height      int32  `json:"height"`
+	Blurhash    string `json:"blurhash"`
+}
+
+// loadMediaVariants fetches the variants of several uploads at once,
+// keyed by media ID.
+func (cfg *apiConfig) loadMediaVariants(ctx context.Context, mediaIDs []uuid.UUID) (map[uuid.UUID][]mediaVariantJSON, error) {
+	variants := map[uuid.UUID][]mediaVariantJSON{}
+	if len(mediaIDs) == 0 {
+		return variants, nil
+	}
+
+	rows, err := cfg.db.ListMediaVariantsByMediaIDs(ctx, mediaIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		variants[row.MediaID] = append(variants[row.MediaID], mediaVariantJSON{
+			Name:        row.Name,
+			URL:         cfg.blobs.URL(row.BlobKey),
+			ContentType: row.ContentType,
+			Width:       row.Width,
+			Height:      row.Height,
+			Blurhash:    row.Blurhash,
+		})
+	}
+	return variants, nil
+}
+
+// enqueueMedia hands a new upload to the worker pool. When the queue is
+// full the upload is left for the sweeper instead of blocking the request.
+func (cfg *apiConfig) enqueueMedia(mediaID uuid.UUID) {
+	select {
+	case cfg.mediaJobs <- mediaID:
+	default:
+	}
+}
+
+// runMediaProcessor starts workers goroutines that generate variants for
+// uploads, then sweeps for uploads that were never queued or whose worker
+// died, once per mediaSweepInterval until ctx is cancelled.
+func (cfg *apiConfig) runMediaProcessor(ctx context.Context, workers int) {
+	for range workers {
+		go cfg.mediaWorker(ctx)
+	}
+
+	ticker := time.NewTicker(mediaSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		ids, err := cfg.db.ListUnprocessedMediaIDs(ctx, database.ListUnprocessedMediaIDsParams{
+			CreatedBefore: now.Add(-mediaSweepInterval),
+			StaleBefore:   now.Add(-mediaClaimTimeout),
+			MaxRows:       mediaSweepBatch,
+		})
+		if err != nil {
+			log.Printf("Error listing unprocessed media: %s", err)
+		}
+		for _, id := range ids {
+			select {
+			case <-ctx.Done():
+				return
+			case cfg.mediaJobs <- id:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) mediaWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-cfg.mediaJobs:
+			if err := cfg.processMedia(ctx, id); err != nil {
+				log.Printf("Error processing media %s: %s", id, err)
+			}
+		}
+	}
+}
+
+// processMedia generates and stores the variants of one upload. Uploads
+// that are already processed, or claimed by another worker, are skipped.
+func (cfg *apiConfig) processMedia(ctx context.Context, mediaID uuid.UUID) error {
+	m, err := cfg.db.ClaimMedia(ctx, database.ClaimMediaParams{
+		ID:          mediaID,
+		StaleBefore: time.Now().Add(-mediaClaimTimeout),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rc, err := cfg.blobs.Get(ctx, m.BlobKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+
+	variants, err := media.MakeVariants(data, mediaVariantSpecs)
+	if errors.Is(err, media.ErrInvalidImage) {
+		// Retrying will not help, so give up and serve the original only.
+		log.Printf("Media %s could not be decoded, skipping variants", m.ID)
+		return cfg.db.MarkMediaProcessed(ctx, m.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, v := range variants {
+		key := fmt.Sprintf("%s-%s%s", m.ID, v.Name, v.Ext)
+		if err := cfg.blobs.Put(ctx, key, bytes.NewReader(v.Data)); err != nil {
+			return err
+		}
+		err := cfg.db.UpsertMediaVariant(ctx, database.UpsertMediaVariantParams{
+			MediaID:     m.ID,
+			Name:        v.Name,
+			BlobKey:     key,
+			ContentType: v.ContentType,
+			Width:       int32(v.Width),
+			Height:      int32(v.Height),
+			Blurhash:    v.Blurhash,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return cfg.db.MarkMediaProcessed(ctx, m.ID)
+}